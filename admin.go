@@ -0,0 +1,81 @@
+package main
+
+import (
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// handleAdminStatus reports the delivery health of every webhook
+func handleAdminStatus(c *gin.Context) {
+	c.JSON(200, gin.H{
+		"webhooks": breaker.status(time.Now()),
+	})
+}
+
+// handleReload re-reads the routing config, keeping the current one if it's invalid
+func (s *Server) handleReload(c *gin.Context) {
+	routes, err := reloadRoutes()
+	if err != nil {
+		slog.Error("Reloading route config failed", "error", err)
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	s.setRoutes(routes)
+	slog.Info("Route config reloaded", "routes", len(routes.Routes), "webhooks", len(routes.allWebhooks()))
+	c.JSON(200, gin.H{"message": "Route config reloaded"})
+}
+
+// handleAdminReplay queues every dead-lettered notification for another delivery attempt
+func handleAdminReplay(c *gin.Context) {
+	if deadLetters == nil {
+		c.JSON(404, gin.H{"error": "DEAD_LETTER_PATH is not configured"})
+		return
+	}
+
+	letters, err := deadLetters.drain()
+	if err != nil {
+		slog.Error("Error reading dead letters", "error", err)
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	queued := 0
+	for _, letter := range letters {
+		job := deliveryJob{
+			destination: letter.Destination,
+			webhookURL:  letter.WebhookURL,
+			messages:    letter.Messages,
+			eventType:   letter.EventType,
+			repo:        letter.Repo,
+			logger:      slog.With("event_type", letter.EventType, "repo", letter.Repo, "destination", letter.Destination, "replay", true),
+		}
+		if enqueueDelivery(job) {
+			queued++
+		} else {
+			deadLetters.record(job, errors.New("delivery queue full during replay"))
+		}
+	}
+	slog.Info("Replaying dead letters", "queued", queued, "total", len(letters))
+	c.JSON(200, gin.H{"queued": queued, "total": len(letters)})
+}
+
+// handleAdminEnableWebhook re-enables a webhook disabled after Discord reported it deleted
+func handleAdminEnableWebhook(c *gin.Context) {
+	var request struct {
+		Webhook string `json:"webhook"` // Redacted URL as shown by /admin/status
+	}
+	if err := c.ShouldBindJSON(&request); err != nil || request.Webhook == "" {
+		c.JSON(400, gin.H{"error": "Expected a JSON body with the webhook to enable"})
+		return
+	}
+
+	if !breaker.enable(request.Webhook) {
+		c.JSON(404, gin.H{"error": "Unknown webhook"})
+		return
+	}
+	slog.Info("Webhook re-enabled by admin", "webhook", request.Webhook)
+	c.JSON(200, gin.H{"message": "Webhook enabled"})
+}
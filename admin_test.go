@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestReplayKeepsLettersForDisabledWebhooks(t *testing.T) {
+	log, err := openDeadLetterLog(filepath.Join(t.TempDir(), "dead-letters.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer log.Close()
+	defer func(previous *deadLetterLog) { deadLetters = previous }(deadLetters)
+	deadLetters = log
+
+	// Discord reported the webhook deleted, so replays can't reach it
+	webhookURL := "https://discord.com/api/webhooks/82/disabled"
+	breaker.record(webhookURL, &discordAPIError{StatusCode: http.StatusNotFound}, time.Now())
+	deadLetters.record(deliveryJob{
+		destination: destinationDiscord,
+		webhookURL:  webhookURL,
+		messages:    []Notification{{Title: "Pull Request opened"}},
+		eventType:   "pull_request",
+		repo:        "octo/repo",
+	}, errWebhookDisabled)
+
+	startDeliveryWorkers(1, 1)
+	defer func() {
+		deliveryQueueMu.Lock()
+		deliveryQueueClosed = false
+		deliveryQueueMu.Unlock()
+	}()
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/admin/replay", handleAdminReplay)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/admin/replay", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", rec.Code, rec.Body.String())
+	}
+
+	// Wait for the replayed job to be processed
+	if err := stopDeliveryWorkers(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	letters, err := deadLetters.drain()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(letters) != 1 {
+		t.Fatalf("%d dead letters left after replay, want 1", len(letters))
+	}
+	if letters[0].WebhookURL != webhookURL || letters[0].Error != errWebhookDisabled.Error() {
+		t.Errorf("dead letter = %+v, want the disabled webhook's notification", letters[0])
+	}
+}
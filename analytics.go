@@ -0,0 +1,60 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// NormalizedEvent is the stable, destination-neutral shape of a notified
+// event sent to ANALYTICS_URL
+type NormalizedEvent struct {
+	Type      string    `json:"type"`
+	Action    string    `json:"action,omitempty"`
+	Repo      string    `json:"repo"`
+	Actor     string    `json:"actor"`
+	URL       string    `json:"url,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// HTTP client used for analytics requests
+var analyticsClient = &http.Client{Timeout: 10 * time.Second}
+
+// normalizeEvent builds the analytics record for a notified event
+func normalizeEvent(eventType string, event GitHubEvent, message Notification) NormalizedEvent {
+	return NormalizedEvent{
+		Type:      eventType,
+		Action:    eventOutcome(eventType, event),
+		Repo:      event.Repository.FullName,
+		Actor:     event.Sender.Login,
+		URL:       message.URL,
+		Timestamp: message.Timestamp,
+	}
+}
+
+func sendAnalyticsEvent(analyticsURL string, event NormalizedEvent) error {
+	jsonData, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("marshaling analytics event: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, analyticsURL, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return fmt.Errorf("creating analytics request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	signBridgePayload(req.Header, jsonData)
+
+	resp, err := analyticsClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("sending analytics event: %w", redactError(err))
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("analytics endpoint returned status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"encoding/json"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+// auditEntry is one line of the audit log
+type auditEntry struct {
+	Time        time.Time `json:"time"`
+	DeliveryID  string    `json:"delivery_id"`
+	EventType   string    `json:"event_type"`
+	Repo        string    `json:"repo"`
+	Sender      string    `json:"sender"`
+	Destination string    `json:"destination,omitempty"`
+	Result      string    `json:"result"`
+	Error       string    `json:"error,omitempty"`
+}
+
+// auditLog appends processed webhooks to a JSONL file
+type auditLog struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+// Audit log of processed webhooks, nil when AUDIT_LOG_PATH is unset
+var audit *auditLog
+
+func openAuditLog(path string) (*auditLog, error) {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	return &auditLog{file: file}, nil
+}
+
+// record appends an entry with its outcome, doing nothing when auditing is disabled
+func (a *auditLog) record(entry auditEntry, result string, err error) {
+	if a == nil {
+		return
+	}
+
+	entry.Time = time.Now().UTC()
+	entry.Result = result
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	line, marshalErr := json.Marshal(entry)
+	if marshalErr != nil {
+		slog.Error("Error marshaling audit entry", "error", marshalErr)
+		return
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if _, err := a.file.Write(append(line, '\n')); err != nil {
+		slog.Error("Error writing audit log", "error", err)
+	}
+}
+
+func (a *auditLog) Close() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.file.Close()
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// notificationBatcher buffers notifications that arrive close together so
+// they can be delivered as a single message
+type notificationBatcher struct {
+	mu      sync.Mutex
+	window  time.Duration
+	pending map[string]*deliveryJob
+}
+
+// Pending notification batches, nil when batching is disabled
+var batches *notificationBatcher
+
+func newNotificationBatcher(window time.Duration) *notificationBatcher {
+	return &notificationBatcher{
+		window:  window,
+		pending: make(map[string]*deliveryJob),
+	}
+}
+
+// add buffers a job under key for its webhook, flushing the batch once the window has passed
+func (b *notificationBatcher) add(key string, job deliveryJob) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	key = job.webhookURL + "|" + key
+	if pending, ok := b.pending[key]; ok {
+		pending.messages = append(pending.messages, job.messages...)
+		pending.audit = append(pending.audit, job.audit...)
+		return
+	}
+
+	b.pending[key] = &job
+	time.AfterFunc(b.window, func() { b.flush(key) })
+}
+
+// flush hands a pending batch to the delivery workers
+func (b *notificationBatcher) flush(key string) {
+	b.mu.Lock()
+	job, ok := b.pending[key]
+	delete(b.pending, key)
+	b.mu.Unlock()
+
+	if ok {
+		enqueueDelivery(*job)
+	}
+}
+
+// flushAll hands every pending batch to the delivery workers, used during shutdown
+func (b *notificationBatcher) flushAll() {
+	b.mu.Lock()
+	pending := b.pending
+	b.pending = make(map[string]*deliveryJob)
+	b.mu.Unlock()
+
+	for _, job := range pending {
+		enqueueDelivery(*job)
+	}
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Delivery retry and circuit breaker tuning
+const (
+	deliveryAttempts = 3
+	retryBackoff     = time.Second
+	breakerThreshold = 5
+	breakerCooldown  = time.Minute
+)
+
+// Returned instead of sending while a webhook's circuit breaker is open
+var errCircuitOpen = errors.New("circuit breaker open, skipping delivery")
+
+// Returned instead of sending to a webhook Discord reported as deleted
+var errWebhookDisabled = errors.New("webhook disabled after Discord returned 404")
+
+// webhookHealth tracks recent delivery results for one webhook
+type webhookHealth struct {
+	lastSuccess         time.Time
+	lastFailure         time.Time
+	consecutiveFailures int
+	openUntil           time.Time
+
+	// Set when the webhook no longer exists, cleared only by an admin or a restart
+	disabled bool
+}
+
+// circuitBreaker stops deliveries to webhooks that keep failing until a cooldown has passed
+type circuitBreaker struct {
+	mu       sync.Mutex
+	webhooks map[string]*webhookHealth
+}
+
+var breaker = &circuitBreaker{webhooks: make(map[string]*webhookHealth)}
+
+// allow returns an error when a delivery to webhookURL must not be attempted
+func (b *circuitBreaker) allow(webhookURL string, now time.Time) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	health, ok := b.webhooks[webhookURL]
+	switch {
+	case !ok:
+		return nil
+	case health.disabled:
+		return errWebhookDisabled
+	case now.Before(health.openUntil):
+		return errCircuitOpen
+	}
+	return nil
+}
+
+// record updates a webhook's health with the outcome of a delivery, opening
+// the breaker once too many deliveries in a row have failed
+func (b *circuitBreaker) record(webhookURL string, err error, now time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	health, ok := b.webhooks[webhookURL]
+	if !ok {
+		health = &webhookHealth{}
+		b.webhooks[webhookURL] = health
+	}
+
+	if err == nil {
+		health.lastSuccess = now
+		health.consecutiveFailures = 0
+		health.openUntil = time.Time{}
+		return
+	}
+
+	health.lastFailure = now
+	health.consecutiveFailures++
+
+	// A deleted webhook will never come back, stop trying until re-enabled
+	var apiErr *discordAPIError
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound && !health.disabled {
+		health.disabled = true
+		slog.Error("Discord webhook not found, disabling it until re-enabled", "webhook", redactWebhookURL(webhookURL))
+	}
+	if health.consecutiveFailures >= breakerThreshold {
+		health.openUntil = now.Add(breakerCooldown)
+		slog.Error("Circuit breaker opened", "webhook", redactWebhookURL(webhookURL), "failures", health.consecutiveFailures, "error", err)
+		reportBreakerOpened(webhookURL, err)
+	}
+}
+
+// enable clears the disabled flag and failure count of the webhook with the
+// given redacted URL, reporting whether one was found
+func (b *circuitBreaker) enable(redacted string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	found := false
+	for webhookURL, health := range b.webhooks {
+		if redactWebhookURL(webhookURL) == redacted {
+			health.disabled = false
+			health.consecutiveFailures = 0
+			health.openUntil = time.Time{}
+			found = true
+		}
+	}
+	return found
+}
+
+// webhookStatus is the admin view of a webhook's health
+type webhookStatus struct {
+	Webhook             string     `json:"webhook"`
+	LastSuccess         *time.Time `json:"last_success,omitempty"`
+	LastFailure         *time.Time `json:"last_failure,omitempty"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	CircuitOpen         bool       `json:"circuit_open"`
+	Disabled            bool       `json:"disabled"`
+}
+
+// status returns the health of every webhook delivered to so far
+func (b *circuitBreaker) status(now time.Time) []webhookStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	statuses := make([]webhookStatus, 0, len(b.webhooks))
+	for webhookURL, health := range b.webhooks {
+		status := webhookStatus{
+			Webhook:             redactWebhookURL(webhookURL),
+			ConsecutiveFailures: health.consecutiveFailures,
+			CircuitOpen:         now.Before(health.openUntil),
+			Disabled:            health.disabled,
+		}
+		if !health.lastSuccess.IsZero() {
+			lastSuccess := health.lastSuccess
+			status.LastSuccess = &lastSuccess
+		}
+		if !health.lastFailure.IsZero() {
+			lastFailure := health.lastFailure
+			status.LastFailure = &lastFailure
+		}
+		statuses = append(statuses, status)
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Webhook < statuses[j].Webhook })
+	return statuses
+}
+
+// redactWebhookURL hides the secret token at the end of a webhook URL
+func redactWebhookURL(webhookURL string) string {
+	u, err := url.Parse(webhookURL)
+	if err != nil || u.Host == "" {
+		return "redacted"
+	}
+	u.Path = path.Join(path.Dir(u.Path), "redacted")
+	u.RawQuery = ""
+	return u.String()
+}
+
+// redactError masks the webhook token in the URL of a *url.Error, which the
+// HTTP client includes in the error text. Call it before wrapping err, since
+// wrapped errors keep the original text. Message edit URLs lose their message ID too.
+func redactError(err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		webhookURL, _, _ := strings.Cut(urlErr.URL, "/messages/")
+		urlErr.URL = redactWebhookURL(webhookURL)
+	}
+	return err
+}
+
+// isRetryable reports whether a failed delivery may succeed if sent again
+func isRetryable(err error) bool {
+	if errors.Is(err, errCircuitOpen) || errors.Is(err, errWebhookDisabled) || errors.Is(err, errRateLimitTooLong) || errors.Is(err, context.Canceled) {
+		return false
+	}
+	// Discord client errors won't change on retry
+	var apiErr *discordAPIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode >= 500
+	}
+	return true
+}
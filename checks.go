@@ -0,0 +1,99 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+)
+
+// GitHub check run payload structure
+type CheckRun struct {
+	Name       string `json:"name"`
+	Status     string `json:"status"`
+	Conclusion string `json:"conclusion"`
+	HTMLURL    string `json:"html_url"`
+	HeadSHA    string `json:"head_sha"`
+}
+
+func (s *Server) handleCheckRunEvent(event GitHubEvent) *Notification {
+	slog.Info("Processing check run event", "action", event.Action)
+
+	// Only process completed check runs
+	if event.Action != "completed" {
+		slog.Info("Ignoring check run action", "action", event.Action)
+		return nil
+	}
+
+	// Create the notification
+	message := Notification{
+		Title: fmt.Sprintf("Check Run %s", event.CheckRun.Conclusion),
+		Description: fmt.Sprintf("Check **%s** %s",
+			escapeMarkdown(event.CheckRun.Name),
+			event.CheckRun.Conclusion),
+		Color: colorFor("workflow", event.CheckRun.Conclusion),
+		URL:   event.CheckRun.HTMLURL,
+		Fields: []NotificationField{
+			{
+				Name:   "Repository",
+				Value:  fmt.Sprintf("[%s](%s)", event.Repository.FullName, event.Repository.HTMLURL),
+				Inline: true,
+			},
+			{
+				Name:   "Commit",
+				Value:  fmt.Sprintf("`%s`", shortSHA(event.CheckRun.HeadSHA)),
+				Inline: true,
+			},
+		},
+		Username:  s.ciIdentity.Username,
+		AvatarURL: s.ciIdentity.AvatarURL,
+	}
+
+	return &message
+}
+
+// GitHub check suite payload structure, the rollup of a commit's check runs
+type CheckSuite struct {
+	Status     string `json:"status"`
+	Conclusion string `json:"conclusion"`
+	HeadBranch string `json:"head_branch"`
+	HeadSHA    string `json:"head_sha"`
+	URL        string `json:"url"` // API URL, the suite has no HTML page of its own
+}
+
+func (s *Server) handleCheckSuiteEvent(event GitHubEvent) *Notification {
+	slog.Info("Processing check suite event", "action", event.Action)
+
+	// Only process completed check suites
+	if event.Action != "completed" {
+		slog.Info("Ignoring check suite action", "action", event.Action)
+		return nil
+	}
+
+	suite := event.CheckSuite
+	checksURL := fmt.Sprintf("%s/commit/%s/checks", event.Repository.HTMLURL, suite.HeadSHA)
+
+	// Create the notification
+	message := Notification{
+		Title: fmt.Sprintf("Check Suite %s", suite.Conclusion),
+		Description: fmt.Sprintf("Checks on `%s` finished: %s",
+			suite.HeadBranch,
+			formatConclusion(suite.Conclusion)),
+		Color: colorFor("workflow", suite.Conclusion),
+		URL:   checksURL,
+		Fields: []NotificationField{
+			{
+				Name:   "Repository",
+				Value:  fmt.Sprintf("[%s](%s)", event.Repository.FullName, event.Repository.HTMLURL),
+				Inline: true,
+			},
+			{
+				Name:   "Commit",
+				Value:  fmt.Sprintf("[`%s`](%s)", shortSHA(suite.HeadSHA), checksURL),
+				Inline: true,
+			},
+		},
+		Username:  s.ciIdentity.Username,
+		AvatarURL: s.ciIdentity.AvatarURL,
+	}
+
+	return &message
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Default embed colors keyed by "<event>_<action>", overridable with COLOR_<EVENT>_<ACTION>
+var defaultColors = map[string]int{
+	"pr_default":  0x1D82F7, // Blue, also used for freshly opened PRs
+	"pr_reopened": 0x5DADE2, // Light blue, distinct from freshly opened
+	"pr_merged":   0x6E48CD, // Purple
+
+	"review_default":           0x95A5A6, // Gray for comments
+	"review_approved":          0x2ECC71, // Green
+	"review_changes_requested": 0xE67E22, // Orange
+
+	"workflow_default":   0xE6E6E6, // Gray for unknown status
+	"workflow_success":   0x2ECC71, // Green
+	"workflow_failure":   0xE74C3C, // Red
+	"workflow_cancelled": 0xF39C12, // Yellow-Orange
+	"workflow_skipped":   0x95A5A6, // Gray-Blue
+	"workflow_started":   0x95A5A6, // Gray-Blue
+	"workflow_timed_out": 0xE74C3C, // Red, same as failure
+
+	"workflow_action_required": 0xE67E22, // Orange
+
+	"deploy_default": 0xE6E6E6, // Gray for unknown states
+	"deploy_success": 0x2ECC71, // Green
+	"deploy_failure": 0xE74C3C, // Red
+
+	"push_default": 0x1D82F7, // Blue for branch pushes
+	"push_tag":     0xF1C40F, // Gold for tags
+	"push_forced":  0xE74C3C, // Red for force pushes
+
+	"release_default":    0x2ECC71, // Green for stable releases
+	"release_prerelease": 0xF39C12, // Orange for prereleases
+
+	"issue_default": 0x2ECC71, // Green for open issues
+	"issue_closed":  0x95A5A6, // Gray for closed issues
+
+	"discussion_default": 0x5865F2, // Blurple
+
+	"comment_default": 0x5865F2, // Blurple
+
+	"star_default": 0xF1C40F, // Gold
+
+	"fork_default": 0x1ABC9C, // Teal
+
+	"startup_default": 0x2ECC71, // Green
+
+	"ref_create": 0x2ECC71, // Green
+	"ref_delete": 0xE74C3C, // Red
+
+	"membership_added":   0x2ECC71, // Green
+	"membership_removed": 0xE74C3C, // Red
+
+	"installation_created": 0x2ECC71, // Green
+	"installation_added":   0x2ECC71, // Green
+	"installation_deleted": 0xE74C3C, // Red
+	"installation_removed": 0xE74C3C, // Red
+}
+
+// Color used when neither the action nor the event has a default
+const fallbackColor = 0xE6E6E6
+
+// colorFor returns the embed color for an event action, preferring env var
+// overrides and falling back to the built-in defaults
+func colorFor(event, action string) int {
+	key := event + "_" + action
+	envKey := "COLOR_" + strings.ToUpper(key)
+	if value := os.Getenv(envKey); value != "" {
+		color, err := parseColor(value)
+		if err == nil {
+			return color
+		}
+		slog.Warn("Ignoring invalid color override", "key", envKey, "error", err)
+	}
+
+	if color, ok := defaultColors[key]; ok {
+		return color
+	}
+	if color, ok := defaultColors[event+"_default"]; ok {
+		return color
+	}
+	return fallbackColor
+}
+
+// parseColor parses a hex color such as "#1D82F7", "0x1D82F7" or "1D82F7"
+func parseColor(value string) (int, error) {
+	hex := strings.TrimPrefix(strings.TrimPrefix(strings.ToLower(value), "#"), "0x")
+	color, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil || len(hex) != 6 {
+		return 0, fmt.Errorf("invalid hex color %q", value)
+	}
+	return int(color), nil
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+)
+
+// Maximum length of the comment quoted in the notification
+const maxCommentLength = 500
+
+// GitHub comment payload structure
+type IssueComment struct {
+	Body    string `json:"body"`
+	HTMLURL string `json:"html_url"`
+	User    Sender `json:"user"`
+}
+
+// GitHub commit comment payload structure, sent under the same "comment" key
+// as issue comments
+type CommitComment struct {
+	IssueComment
+	CommitID string `json:"commit_id"`
+}
+
+func (s *Server) handleIssueCommentEvent(event GitHubEvent) *Notification {
+	slog.Info("Processing issue comment event", "action", event.Action)
+
+	// Edits and deletions aren't mirrored
+	if event.Action != "created" {
+		slog.Info("Ignoring issue comment action", "action", event.Action)
+		return nil
+	}
+
+	// Comments on pull requests arrive as issue comments too
+	kind := "issue"
+	if event.Issue.PullRequest != nil {
+		kind = "pull request"
+	}
+
+	// Create the notification
+	return &Notification{
+		Title: fmt.Sprintf("New comment on %s #%d", kind, event.Issue.Number),
+		Description: fmt.Sprintf("**%s** commented on [#%d: %s](%s)\n%s",
+			event.Comment.User.Login,
+			event.Issue.Number,
+			escapeMarkdown(event.Issue.Title),
+			event.Comment.HTMLURL,
+			quote(truncate(event.Comment.Body, maxCommentLength))),
+		Color: colorFor("comment", event.Action),
+		URL:   event.Comment.HTMLURL,
+		Fields: []NotificationField{
+			{
+				Name:   "Repository",
+				Value:  fmt.Sprintf("[%s](%s)", event.Repository.FullName, event.Repository.HTMLURL),
+				Inline: true,
+			},
+		},
+	}
+}
+
+func (s *Server) handleCommitCommentEvent(event GitHubEvent) *Notification {
+	slog.Info("Processing commit comment event", "action", event.Action)
+
+	// Edits and deletions aren't mirrored
+	if event.Action != "created" {
+		slog.Info("Ignoring commit comment action", "action", event.Action)
+		return nil
+	}
+
+	commitURL := fmt.Sprintf("%s/commit/%s", event.Repository.HTMLURL, event.Comment.CommitID)
+
+	// Create the notification
+	return &Notification{
+		Title: fmt.Sprintf("New comment on commit %s", shortSHA(event.Comment.CommitID)),
+		Description: fmt.Sprintf("**%s** commented on [`%s`](%s)\n%s",
+			event.Comment.User.Login,
+			shortSHA(event.Comment.CommitID),
+			commitURL,
+			quote(truncate(event.Comment.Body, maxCommentLength))),
+		Color: colorFor("comment", event.Action),
+		URL:   event.Comment.HTMLURL,
+		Fields: []NotificationField{
+			{
+				Name:   "Repository",
+				Value:  fmt.Sprintf("[%s](%s)", event.Repository.FullName, event.Repository.HTMLURL),
+				Inline: true,
+			},
+		},
+	}
+}
+
+// quote formats text as a markdown block quote
+func quote(text string) string {
+	lines := strings.Split(strings.TrimSpace(text), "\n")
+	for i, line := range lines {
+		lines[i] = "> " + line
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,585 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"net"
+	"net/netip"
+	"net/url"
+	"os"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// Config holds the settings read from the environment at startup
+type Config struct {
+	Destination string
+	DryRun      bool
+	DebugEcho   bool
+
+	// Routing
+	ConfigPath      string
+	Routes          *RouteConfig
+	TeamsWebhookURL string
+	ForwardURL      string
+	AnalyticsURL    string
+	SigningKey      string
+
+	// Inbound requests
+	WebhookSecret      string
+	MaxBodyBytes       int64
+	GitHubHost         string
+	RepoAllowlist      []string
+	IgnoredSenders     []string
+	DedupWindow        time.Duration
+	ContentDedupWindow time.Duration
+	AllowedOrigins     []string
+	AdminToken         string
+	RateLimitRPS       float64
+	RateLimitBurst     int
+	IPAllowlist        bool
+	IPCIDRs            []netip.Prefix
+	TrustedProxies     []string
+
+	// Notification content
+	Templates                map[string]*template.Template
+	PRIdentity               botIdentity
+	CIIdentity               botIdentity
+	PRBranchFilter           []string
+	RefFilter                *regexp.Regexp
+	NotifyPRSync             bool
+	TriggerLabel             string
+	WorkflowNotifyStarted    bool
+	WorkflowSuccessAllowlist []string
+	MentionOnFailure         string
+	MentionOnCancel          string
+	MentionOnForcePush       string
+	EmojiEnabled             bool
+	MessagePrefix            string
+	MessageSuffix            string
+	EnabledEvents            map[string]bool
+	PushSummaryThreshold     int
+	MinSeverity              severity
+
+	// Delivery
+	DeliveryTimeout     time.Duration
+	RetryJitter         bool
+	DeliveryWorkers     int
+	DeliveryQueueSize   int
+	BatchWindow         time.Duration
+	MaxEmbedsPerMessage int
+	SentryDSN           string
+	AuditLogPath        string
+	DeadLetterPath      string
+
+	// Startup announcement, sent to the dev channel webhooks
+	PostStartupMessage bool
+	StartupWebhooks    []string
+
+	// Listener
+	Addr        string
+	AdminAddr   string
+	TLSCertFile string
+	TLSKeyFile  string
+}
+
+// Settings that may also be read from a file named by <KEY>_FILE
+var secretKeys = []string{
+	"DISCORD_DEV_WEBHOOK_URL",
+	"DISCORD_TEST_WEBHOOK_URL",
+	"DISCORD_ADMIN_WEBHOOK_URL",
+	"TEAMS_WEBHOOK_URL",
+	"FORWARD_URL",
+	"ANALYTICS_URL",
+	"REPO_WEBHOOKS",
+	"LABEL_ROUTES",
+	"GITHUB_WEBHOOK_SECRET",
+	"ADMIN_TOKEN",
+	"SENTRY_DSN",
+	"SIGNING_KEY",
+}
+
+// loadConfig reads and validates every env var, failing on the first invalid value
+func loadConfig() (*Config, error) {
+	// Resolve secrets mounted as files before reading anything else
+	secrets, err := readSecrets()
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := &Config{
+		Destination:              destinationDiscord,
+		TeamsWebhookURL:          secrets["TEAMS_WEBHOOK_URL"],
+		ForwardURL:               secrets["FORWARD_URL"],
+		AnalyticsURL:             secrets["ANALYTICS_URL"],
+		SigningKey:               secrets["SIGNING_KEY"],
+		WebhookSecret:            secrets["GITHUB_WEBHOOK_SECRET"],
+		RepoAllowlist:            splitList(os.Getenv("REPO_ALLOWLIST")),
+		IgnoredSenders:           splitList(os.Getenv("IGNORE_SENDERS")),
+		AllowedOrigins:           splitList(os.Getenv("ALLOWED_ORIGINS")),
+		AdminToken:               secrets["ADMIN_TOKEN"],
+		PRIdentity:               botIdentity{Username: os.Getenv("PR_USERNAME"), AvatarURL: os.Getenv("PR_AVATAR_URL")},
+		CIIdentity:               botIdentity{Username: os.Getenv("CI_USERNAME"), AvatarURL: os.Getenv("CI_AVATAR_URL")},
+		PRBranchFilter:           splitList(os.Getenv("PR_BRANCH_FILTER")),
+		TriggerLabel:             os.Getenv("TRIGGER_LABEL"),
+		WorkflowSuccessAllowlist: splitList(os.Getenv("WORKFLOW_SUCCESS_ALLOWLIST")),
+		MentionOnFailure:         os.Getenv("MENTION_ON_FAILURE"),
+		MentionOnCancel:          os.Getenv("MENTION_ON_CANCEL"),
+		MentionOnForcePush:       os.Getenv("MENTION_ON_FORCE_PUSH"),
+		MessagePrefix:            os.Getenv("MESSAGE_PREFIX"),
+		MessageSuffix:            os.Getenv("MESSAGE_SUFFIX"),
+		SentryDSN:                secrets["SENTRY_DSN"],
+		AuditLogPath:             os.Getenv("AUDIT_LOG_PATH"),
+		DeadLetterPath:           os.Getenv("DEAD_LETTER_PATH"),
+		StartupWebhooks:          splitList(secrets["DISCORD_DEV_WEBHOOK_URL"]),
+		TLSCertFile:              os.Getenv("TLS_CERT_FILE"),
+		TLSKeyFile:               os.Getenv("TLS_KEY_FILE"),
+	}
+
+	// Boolean switches, rejecting values other than true/false, 1/0 and the like
+	for _, flag := range []struct {
+		key   string
+		value *bool
+	}{
+		{"DRY_RUN", &cfg.DryRun},
+		{"DEBUG_ECHO", &cfg.DebugEcho},
+		{"GITHUB_IP_ALLOWLIST", &cfg.IPAllowlist},
+		{"NOTIFY_PR_SYNC", &cfg.NotifyPRSync},
+		{"WORKFLOW_NOTIFY_STARTED", &cfg.WorkflowNotifyStarted},
+		{"EMOJI_ENABLED", &cfg.EmojiEnabled},
+		{"POST_STARTUP_MESSAGE", &cfg.PostStartupMessage},
+	} {
+		if *flag.value, err = envBool(flag.key); err != nil {
+			return nil, err
+		}
+	}
+
+	// Select where notifications are delivered
+	if value := os.Getenv("DESTINATION"); value != "" {
+		if value != destinationDiscord && value != destinationSlack {
+			return nil, fmt.Errorf("invalid DESTINATION %q, expected %s or %s", value, destinationDiscord, destinationSlack)
+		}
+		cfg.Destination = value
+	}
+
+	// Load the routing config, falling back to the env var webhooks
+	if err := cfg.loadRoutes(secrets); err != nil {
+		return nil, err
+	}
+
+	// Every webhook must at least be an absolute URL
+	webhooks := cfg.Routes.allWebhooks()
+	if cfg.TeamsWebhookURL != "" {
+		webhooks = append(webhooks, cfg.TeamsWebhookURL)
+	}
+	if cfg.ForwardURL != "" {
+		webhooks = append(webhooks, cfg.ForwardURL)
+	}
+	if cfg.AnalyticsURL != "" {
+		webhooks = append(webhooks, cfg.AnalyticsURL)
+	}
+	if err := validateWebhooks(webhooks); err != nil {
+		return nil, err
+	}
+
+	// Routed Discord webhooks must also carry an ID and token, dry runs
+	// only warn since nothing is sent
+	if cfg.Destination == destinationDiscord {
+		if err := validateDiscordWebhooks(cfg.Routes.allWebhooks()); err != nil {
+			if !cfg.DryRun {
+				return nil, err
+			}
+			slog.Warn("Ignoring invalid Discord webhook in dry run", "error", err)
+		}
+	}
+
+	// Numeric settings
+	maxBody, err := envInt("MAX_BODY_BYTES", 5<<20)
+	if err != nil || maxBody == 0 {
+		return nil, fmt.Errorf("invalid MAX_BODY_BYTES: %q", os.Getenv("MAX_BODY_BYTES"))
+	}
+	cfg.MaxBodyBytes = int64(maxBody)
+
+	timeoutSeconds, err := envInt("DISCORD_TIMEOUT_SECONDS", 10)
+	if err != nil || timeoutSeconds == 0 {
+		return nil, fmt.Errorf("invalid DISCORD_TIMEOUT_SECONDS: %q", os.Getenv("DISCORD_TIMEOUT_SECONDS"))
+	}
+	cfg.DeliveryTimeout = time.Duration(timeoutSeconds) * time.Second
+
+	// Jittered retries are the default, disable for deterministic timing
+	cfg.RetryJitter = true
+	if value := os.Getenv("RETRY_JITTER"); value != "" {
+		if cfg.RetryJitter, err = strconv.ParseBool(value); err != nil {
+			return nil, fmt.Errorf("invalid RETRY_JITTER: %q", value)
+		}
+	}
+
+	dedupWindow, err := envInt("DEDUP_WINDOW_MINUTES", 10)
+	if err != nil {
+		return nil, err
+	}
+	cfg.DedupWindow = time.Duration(dedupWindow) * time.Minute
+
+	contentDedupWindow, err := envInt("CONTENT_DEDUP_WINDOW_MS", 0)
+	if err != nil {
+		return nil, err
+	}
+	cfg.ContentDedupWindow = time.Duration(contentDedupWindow) * time.Millisecond
+
+	cfg.DeliveryWorkers, err = envInt("DELIVERY_WORKERS", 4)
+	if err != nil || cfg.DeliveryWorkers == 0 {
+		return nil, fmt.Errorf("invalid DELIVERY_WORKERS: %q", os.Getenv("DELIVERY_WORKERS"))
+	}
+	cfg.DeliveryQueueSize, err = envInt("DELIVERY_QUEUE_SIZE", 100)
+	if err != nil {
+		return nil, err
+	}
+
+	batchWindow, err := envInt("BATCH_WINDOW_MS", 0)
+	if err != nil {
+		return nil, err
+	}
+	cfg.BatchWindow = time.Duration(batchWindow) * time.Millisecond
+
+	cfg.MaxEmbedsPerMessage, err = envInt("MAX_EMBEDS_PER_MESSAGE", maxEmbedsPerMessage)
+	if err != nil || cfg.MaxEmbedsPerMessage == 0 || cfg.MaxEmbedsPerMessage > maxEmbedsPerMessage {
+		return nil, fmt.Errorf("invalid MAX_EMBEDS_PER_MESSAGE: %q, expected 1-%d", os.Getenv("MAX_EMBEDS_PER_MESSAGE"), maxEmbedsPerMessage)
+	}
+
+	// Inbound rate limit per client IP, zero disables it
+	if value := os.Getenv("RATE_LIMIT_RPS"); value != "" {
+		cfg.RateLimitRPS, err = strconv.ParseFloat(value, 64)
+		if err != nil || cfg.RateLimitRPS < 0 {
+			return nil, fmt.Errorf("invalid RATE_LIMIT_RPS: %q", value)
+		}
+	}
+	cfg.RateLimitBurst, err = envInt("RATE_LIMIT_BURST", 20)
+	if err != nil || cfg.RateLimitBurst == 0 {
+		return nil, fmt.Errorf("invalid RATE_LIMIT_BURST: %q", os.Getenv("RATE_LIMIT_BURST"))
+	}
+
+	// Proxies whose X-Forwarded-For is believed when resolving the client IP
+	// used by the rate limiter and IP allowlist, loopback only by default
+	cfg.TrustedProxies = []string{"127.0.0.1/8", "::1/128"}
+	if value, ok := os.LookupEnv("TRUSTED_PROXIES"); ok {
+		cfg.TrustedProxies = splitList(value)
+	}
+	if _, err := parseCIDRs(cfg.TrustedProxies); err != nil {
+		return nil, fmt.Errorf("invalid TRUSTED_PROXIES: %w", err)
+	}
+
+	// Explicit webhook source ranges, fetched from GitHub's meta API when unset
+	if cfg.IPCIDRs, err = parseCIDRs(splitList(os.Getenv("GITHUB_IP_CIDRS"))); err != nil {
+		return nil, fmt.Errorf("invalid GITHUB_IP_CIDRS: %w", err)
+	}
+
+	// Patterns and colors
+	for _, pattern := range cfg.PRBranchFilter {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid PR_BRANCH_FILTER pattern %q: %w", pattern, err)
+		}
+	}
+	if pattern := os.Getenv("REF_FILTER"); pattern != "" {
+		cfg.RefFilter, err = regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid REF_FILTER: %w", err)
+		}
+	}
+	for _, env := range os.Environ() {
+		key, value, _ := strings.Cut(env, "=")
+		if strings.HasPrefix(key, "COLOR_") {
+			if _, err := parseColor(value); err != nil {
+				return nil, fmt.Errorf("invalid %s: %w", key, err)
+			}
+		}
+	}
+
+	if baseURL := os.Getenv("GITHUB_BASE_URL"); baseURL != "" {
+		cfg.GitHubHost, err = parseBaseURL(baseURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid GITHUB_BASE_URL: %w", err)
+		}
+	}
+
+	if cfg.PushSummaryThreshold, err = envInt("PUSH_SUMMARY_THRESHOLD", 0); err != nil {
+		return nil, err
+	}
+
+	if cfg.MinSeverity, err = parseSeverity(os.Getenv("MIN_SEVERITY")); err != nil {
+		return nil, fmt.Errorf("invalid MIN_SEVERITY: %w", err)
+	}
+
+	// Per-event switches, unset flags leave the event enabled
+	if cfg.EnabledEvents, err = loadEventFlags(); err != nil {
+		return nil, err
+	}
+
+	// Custom wording for notification descriptions
+	if templatesDir := os.Getenv("TEMPLATES_DIR"); templatesDir != "" {
+		cfg.Templates, err = loadTemplates(templatesDir)
+		if err != nil {
+			return nil, fmt.Errorf("loading message templates from %s: %w", templatesDir, err)
+		}
+	}
+
+	// Drop non-urgent notifications outside business hours
+	if err := loadQuietHours(); err != nil {
+		return nil, fmt.Errorf("invalid quiet hours configuration: %w", err)
+	}
+
+	// Listener, binding every interface unless HOST is set
+	port := os.Getenv("PORT")
+	if port == "" {
+		port = "8088" // Default port
+	}
+	cfg.Addr = net.JoinHostPort(os.Getenv("HOST"), port)
+
+	// Optional private listener for health, metrics and admin endpoints
+	if adminPort := os.Getenv("ADMIN_PORT"); adminPort != "" {
+		if adminPort == port {
+			return nil, fmt.Errorf("invalid ADMIN_PORT: %q, must differ from PORT", adminPort)
+		}
+		adminHost, ok := os.LookupEnv("ADMIN_HOST")
+		if !ok {
+			adminHost = os.Getenv("HOST")
+		}
+		cfg.AdminAddr = net.JoinHostPort(adminHost, adminPort)
+	}
+
+	if (cfg.TLSCertFile == "") != (cfg.TLSKeyFile == "") {
+		return nil, errors.New("TLS_CERT_FILE and TLS_KEY_FILE must be set together")
+	}
+	for _, file := range []string{cfg.TLSCertFile, cfg.TLSKeyFile} {
+		if file == "" {
+			continue
+		}
+		if _, err := os.Stat(file); err != nil {
+			return nil, fmt.Errorf("reading TLS file: %w", err)
+		}
+	}
+
+	return cfg, nil
+}
+
+// Event types toggled by each ENABLE_<NAME> env var
+var eventFlags = map[string][]string{
+	"PR":          {"pull_request"},
+	"REVIEW":      {"pull_request_review"},
+	"WORKFLOW":    {"workflow_run"},
+	"PUSH":        {"push"},
+	"RELEASE":     {"release"},
+	"ISSUES":      {"issues"},
+	"CHECK_RUN":   {"check_run"},
+	"CHECK_SUITE": {"check_suite"},
+	"DISCUSSION":  {"discussion"},
+	"STAR":        {"star"},
+	"FORK":        {"fork"},
+	"DEPLOYMENT":  {"deployment_status"},
+	"COMMENT":     {"issue_comment", "commit_comment"},
+	"REFS":        {"create", "delete"},
+	"ORG_EVENTS":  {"membership"},
+	"APP_EVENTS":  {"installation", "installation_repositories"},
+}
+
+// Event types that stay off until their ENABLE_* flag is set, as most
+// deployments don't want them or they'd double up with other notifications
+var optInEvents = []string{"membership", "check_suite", "installation", "installation_repositories"}
+
+// loadEventFlags reads the ENABLE_* env vars into a map of event type to
+// whether it's processed, leaving out event types whose flag is unset unless
+// they're opt-in
+func loadEventFlags() (map[string]bool, error) {
+	enabled := make(map[string]bool)
+	for _, eventType := range optInEvents {
+		enabled[eventType] = false
+	}
+	for name, eventTypes := range eventFlags {
+		key := "ENABLE_" + name
+		value := os.Getenv(key)
+		if value == "" {
+			continue
+		}
+		on, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %q", key, value)
+		}
+		for _, eventType := range eventTypes {
+			enabled[eventType] = on
+		}
+	}
+	return enabled, nil
+}
+
+// readSecrets resolves every secret-bearing setting, from the env var or its _FILE path
+func readSecrets() (map[string]string, error) {
+	secrets := make(map[string]string, len(secretKeys))
+	for _, key := range secretKeys {
+		value, err := envOrFile(key)
+		if err != nil {
+			return nil, err
+		}
+		secrets[key] = value
+	}
+	return secrets, nil
+}
+
+// reloadRoutes re-reads and validates the routing config the same way as at startup
+func reloadRoutes() (*RouteConfig, error) {
+	secrets, err := readSecrets()
+	if err != nil {
+		return nil, err
+	}
+	var cfg Config
+	if err := cfg.loadRoutes(secrets); err != nil {
+		return nil, err
+	}
+	if err := validateWebhooks(cfg.Routes.allWebhooks()); err != nil {
+		return nil, err
+	}
+	if destination == destinationDiscord {
+		if err := validateDiscordWebhooks(cfg.Routes.allWebhooks()); err != nil {
+			if !dryRun {
+				return nil, err
+			}
+			slog.Warn("Ignoring invalid Discord webhook in dry run", "error", err)
+		}
+	}
+	return cfg.Routes, nil
+}
+
+// loadRoutes reads the route config file, or builds the default routes from
+// the env var webhooks, then applies the REPO_WEBHOOKS and LABEL_ROUTES overrides
+func (cfg *Config) loadRoutes(secrets map[string]string) error {
+	cfg.ConfigPath = os.Getenv("CONFIG_PATH")
+	if cfg.ConfigPath == "" {
+		cfg.ConfigPath = "config.json" // Default config path
+	}
+
+	routes, err := loadRouteConfig(cfg.ConfigPath)
+	switch {
+	case err == nil:
+		cfg.Routes = routes
+	case errors.Is(err, fs.ErrNotExist):
+		cfg.ConfigPath = ""
+
+		// Get Discord webhook URLs from environment variables, comma-separated
+		// to mirror a channel to several servers
+		developmentChannelWebhooks := splitList(secrets["DISCORD_DEV_WEBHOOK_URL"])
+		testingChannelWebhooks := splitList(secrets["DISCORD_TEST_WEBHOOK_URL"])
+		if len(developmentChannelWebhooks) == 0 || len(testingChannelWebhooks) == 0 {
+			return errors.New("no route config found and Discord webhook URLs not set in environment variables")
+		}
+		adminChannelWebhooks := splitList(secrets["DISCORD_ADMIN_WEBHOOK_URL"])
+		cfg.Routes = defaultRouteConfig(developmentChannelWebhooks, testingChannelWebhooks, adminChannelWebhooks)
+	default:
+		return fmt.Errorf("loading route config %s: %w", cfg.ConfigPath, err)
+	}
+
+	// Route whole repositories to their own webhook
+	repoWebhooks, err := parsePairs(secrets["REPO_WEBHOOKS"])
+	if err != nil {
+		return fmt.Errorf("invalid REPO_WEBHOOKS: %w", err)
+	}
+	if cfg.Routes.RepoWebhooks == nil {
+		cfg.Routes.RepoWebhooks = make(map[string]string)
+	}
+	for repo, webhook := range repoWebhooks {
+		cfg.Routes.RepoWebhooks[repo] = webhook
+	}
+
+	// Route labeled pull requests to team-specific webhooks
+	labelRoutes, err := parsePairs(secrets["LABEL_ROUTES"])
+	if err != nil {
+		return fmt.Errorf("invalid LABEL_ROUTES: %w", err)
+	}
+	if cfg.Routes.LabelRoutes == nil {
+		cfg.Routes.LabelRoutes = make(map[string]string)
+	}
+	for label, webhook := range labelRoutes {
+		cfg.Routes.LabelRoutes[label] = webhook
+	}
+	return nil
+}
+
+// validateURL checks that a webhook is an absolute http(s) URL
+func validateURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return err
+	}
+	if (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return errors.New("expected an absolute http(s) URL")
+	}
+	return nil
+}
+
+// validateWebhooks checks every webhook with validateURL, masking the failing one
+func validateWebhooks(webhooks []string) error {
+	for _, webhook := range webhooks {
+		if err := validateURL(webhook); err != nil {
+			return fmt.Errorf("invalid webhook %s: %w", redactWebhookURL(webhook), err)
+		}
+	}
+	return nil
+}
+
+// Shape of a Discord webhook URL, https://discord.com/api/webhooks/{id}/{token},
+// optionally followed by a query such as ?thread_id=...
+var discordWebhookPattern = regexp.MustCompile(`^https://(?:(?:ptb|canary)\.)?discord(?:app)?\.com/api/(?:v\d+/)?webhooks/\d+/[\w-]+/?(?:\?[^#]*)?$`)
+
+// validateDiscordWebhooks catches mistyped Discord webhooks, e.g. a missing
+// token, before the first delivery fails
+func validateDiscordWebhooks(webhooks []string) error {
+	for _, webhook := range webhooks {
+		if !discordWebhookPattern.MatchString(webhook) {
+			return fmt.Errorf("invalid Discord webhook %s: expected https://discord.com/api/webhooks/{id}/{token}", redactWebhookURL(webhook))
+		}
+	}
+	return nil
+}
+
+// logSummary logs the active configuration with webhook URLs masked
+func (cfg *Config) logSummary() {
+	webhooks := cfg.Routes.allWebhooks()
+	redacted := make([]string, len(webhooks))
+	for i, webhook := range webhooks {
+		redacted[i] = redactWebhookURL(webhook)
+	}
+
+	slog.Info("Configuration loaded",
+		"destination", cfg.Destination,
+		"dry_run", cfg.DryRun,
+		"config_path", cfg.ConfigPath,
+		"routes", len(cfg.Routes.Routes),
+		"webhooks", redacted,
+		"teams", cfg.TeamsWebhookURL != "",
+		"forward", cfg.ForwardURL != "",
+		"analytics", cfg.AnalyticsURL != "",
+		"signed_relays", cfg.SigningKey != "",
+		"signature_verification", cfg.WebhookSecret != "",
+		"repo_allowlist", cfg.RepoAllowlist,
+		"ignored_senders", cfg.IgnoredSenders,
+		"templates", len(cfg.Templates),
+		"delivery_timeout", cfg.DeliveryTimeout.String(),
+		"delivery_workers", cfg.DeliveryWorkers,
+		"batch_window", cfg.BatchWindow.String(),
+		"dedup_window", cfg.DedupWindow.String(),
+		"content_dedup_window", cfg.ContentDedupWindow.String(),
+		"sentry", cfg.SentryDSN != "",
+		"audit_log", cfg.AuditLogPath,
+		"dead_letters", cfg.DeadLetterPath,
+		"admin", cfg.AdminToken != "",
+		"rate_limit_rps", cfg.RateLimitRPS,
+		"ip_allowlist", cfg.IPAllowlist,
+		"trusted_proxies", cfg.TrustedProxies,
+		"addr", cfg.Addr,
+		"admin_addr", cfg.AdminAddr,
+		"tls", cfg.TLSCertFile != "",
+	)
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+)
+
+func TestDevelopmentWebhooksMirrorEveryEvent(t *testing.T) {
+	var received [2]atomic.Int32
+	var webhooks [2]string
+	for i := range received {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			received[i].Add(1)
+			fmt.Fprint(w, `{"id":"1"}`)
+		}))
+		defer srv.Close()
+		webhooks[i] = fmt.Sprintf("%s/api/webhooks/%d/token", srv.URL, i+1)
+	}
+
+	t.Setenv("CONFIG_PATH", filepath.Join(t.TempDir(), "missing.json"))
+	t.Setenv("DISCORD_DEV_WEBHOOK_URL", webhooks[0]+", "+webhooks[1])
+	t.Setenv("DISCORD_TEST_WEBHOOK_URL", "https://discord.com/api/webhooks/3/testing")
+	secrets, err := readSecrets()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := Config{MaxBodyBytes: 1 << 20}
+	if err := cfg.loadRoutes(secrets); err != nil {
+		t.Fatalf("loadRoutes: %v", err)
+	}
+
+	// Deliver synchronously through the real Discord notifier
+	s := newServer(&cfg)
+	s.deliver = deliver
+	rec := serveWebhook(s, newWebhookRequest("pull_request", pullRequestPayload(t, "opened", false)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", rec.Code, rec.Body.String())
+	}
+
+	for i := range received {
+		if got := received[i].Load(); got != 1 {
+			t.Errorf("webhook %d received %d requests, want 1", i+1, got)
+		}
+	}
+}
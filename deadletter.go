@@ -0,0 +1,108 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+// deadLetter is one line of the dead-letter file, holding notifications that
+// failed every delivery attempt
+type deadLetter struct {
+	Time        time.Time      `json:"time"`
+	Destination string         `json:"destination"`
+	WebhookURL  string         `json:"webhook_url"`
+	EventType   string         `json:"event_type"`
+	Repo        string         `json:"repo"`
+	Messages    []Notification `json:"messages"`
+	Error       string         `json:"error"`
+}
+
+// deadLetterLog appends permanently failed deliveries to a JSONL file
+type deadLetterLog struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+// Dead-letter file for failed deliveries, nil when DEAD_LETTER_PATH is unset
+var deadLetters *deadLetterLog
+
+func openDeadLetterLog(path string) (*deadLetterLog, error) {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_RDWR, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	return &deadLetterLog{file: file}, nil
+}
+
+// record appends a failed job, doing nothing when dead-lettering is disabled
+func (d *deadLetterLog) record(job deliveryJob, err error) {
+	if d == nil {
+		return
+	}
+
+	line, marshalErr := json.Marshal(deadLetter{
+		Time:        time.Now().UTC(),
+		Destination: job.destination,
+		WebhookURL:  job.webhookURL,
+		EventType:   job.eventType,
+		Repo:        job.repo,
+		Messages:    job.messages,
+		Error:       err.Error(),
+	})
+	if marshalErr != nil {
+		slog.Error("Error marshaling dead letter", "error", marshalErr)
+		return
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if _, err := d.file.Write(append(line, '\n')); err != nil {
+		slog.Error("Error writing dead letter", "error", err)
+	}
+}
+
+// drain returns every dead letter and empties the file, so deliveries that
+// fail again on replay are appended afresh
+func (d *deadLetterLog) drain() ([]deadLetter, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	data, err := os.ReadFile(d.file.Name())
+	if err != nil {
+		return nil, err
+	}
+
+	var letters []deadLetter
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(nil, 10<<20)
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var letter deadLetter
+		if err := json.Unmarshal(scanner.Bytes(), &letter); err != nil {
+			return nil, fmt.Errorf("parsing dead letter on line %d: %w", line, err)
+		}
+		letters = append(letters, letter)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := d.file.Truncate(0); err != nil {
+		return nil, err
+	}
+	return letters, nil
+}
+
+func (d *deadLetterLog) Close() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.file.Close()
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// deliveryCache remembers keys seen recently, X-GitHub-Delivery IDs or
+// notification content hashes
+type deliveryCache struct {
+	mu     sync.Mutex
+	window time.Duration
+	seen   map[string]time.Time
+}
+
+func newDeliveryCache(window time.Duration) *deliveryCache {
+	return &deliveryCache{
+		window: window,
+		seen:   make(map[string]time.Time),
+	}
+}
+
+// contentHash fingerprints a notification, ignoring when it was processed
+func contentHash(message Notification) string {
+	message.Timestamp = time.Time{}
+	data, _ := json.Marshal(message)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// seenRecently records the delivery ID and reports whether it was already
+// seen within the dedup window
+func (dc *deliveryCache) seenRecently(id string, now time.Time) bool {
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+
+	// Evict entries that have fallen out of the window
+	for key, seenAt := range dc.seen {
+		if now.Sub(seenAt) > dc.window {
+			delete(dc.seen, key)
+		}
+	}
+
+	if _, ok := dc.seen[id]; ok {
+		return true
+	}
+	dc.seen[id] = now
+	return false
+}
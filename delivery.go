@@ -0,0 +1,231 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"math/rand/v2"
+	"sync"
+	"time"
+)
+
+// How long a handler waits for room in a full delivery queue before dropping
+const enqueueTimeout = time.Second
+
+// Default time allowed for each delivery attempt, overridable per destination
+var deliveryTimeout = 10 * time.Second
+
+// Whether retries sleep a random duration up to the backoff ("full jitter")
+// so failed deliveries don't retry in lockstep
+var retryJitter = true
+
+// deliveryJob is one or more notifications waiting to be sent to a webhook
+type deliveryJob struct {
+	destination string
+	webhookURL  string
+	messages    []Notification
+	logger      *slog.Logger
+
+	// Event the notifications were built from, shared by batched jobs
+	eventType string
+	repo      string
+
+	// Per-attempt timeout, zero uses the default
+	timeout time.Duration
+
+	// Audit entries for the requests the job delivers
+	audit []auditEntry
+
+	// Jobs sharing a batch key may be combined while batching is enabled,
+	// empty sends the job on its own
+	batchKey string
+}
+
+// Queue drained by the delivery workers, whose in-flight requests are
+// canceled if shutdown runs out of time
+var (
+	deliveryQueue                 chan deliveryJob
+	deliveryWorkers               sync.WaitGroup
+	deliveryCtx, cancelDeliveries = context.WithCancel(context.Background())
+)
+
+// Guards sends on the delivery queue against it being closed by shutdown,
+// since handlers and batch timers can still enqueue after the HTTP drain times out
+var (
+	deliveryQueueMu     sync.RWMutex
+	deliveryQueueClosed bool
+)
+
+// startDeliveryWorkers starts a fixed pool of workers draining the delivery queue
+func startDeliveryWorkers(workers, queueSize int) {
+	deliveryQueue = make(chan deliveryJob, queueSize)
+	for i := 0; i < workers; i++ {
+		deliveryWorkers.Add(1)
+		go func() {
+			defer deliveryWorkers.Done()
+			for job := range deliveryQueue {
+				deliver(job)
+			}
+		}()
+	}
+}
+
+func deliver(job deliveryJob) {
+	err := deliverWithRetry(job)
+	switch {
+	// Undelivered notifications are kept for a replay once the webhook is
+	// re-enabled or the server is back up
+	case errors.Is(err, context.Canceled):
+		job.logger.Warn("Delivery canceled")
+		deadLetters.record(job, err)
+	case errors.Is(err, errWebhookDisabled):
+		job.logger.Debug("Webhook disabled, skipping delivery")
+		deadLetters.record(job, err)
+	case errors.Is(err, errCircuitOpen):
+		// Already reported once when the breaker opened
+		job.logger.Warn("Circuit breaker open, skipping delivery")
+		deadLetters.record(job, err)
+	case err != nil:
+		job.logger.Error("Error delivering notification", "error", err, discordStatusAttr(err))
+		reportDeliveryFailure(job, err)
+		deadLetters.record(job, err)
+	}
+	job.recordAudit(err)
+}
+
+// deliverWithRetry sends a job with exponential backoff, skipping webhooks
+// whose circuit breaker is open
+func deliverWithRetry(job deliveryJob) error {
+	if err := breaker.allow(job.webhookURL, time.Now()); err != nil {
+		return err
+	}
+
+	timeout := job.timeout
+	if timeout == 0 {
+		timeout = deliveryTimeout
+	}
+
+	// The timeout applies to each request, not to rate limit waits or the
+	// other messages of the job
+	ctx := withRequestTimeout(deliveryCtx, timeout)
+	notifier := newNotifier(job.destination, job.webhookURL)
+	backoff := retryBackoff
+	var err error
+	for attempt := 1; attempt <= deliveryAttempts; attempt++ {
+		err = sendNotifications(ctx, notifier, job.messages)
+		if err == nil || !isRetryable(err) || attempt == deliveryAttempts {
+			break
+		}
+		wait := backoff
+		if retryJitter {
+			wait = time.Duration(rand.Int64N(int64(backoff) + 1))
+		}
+		job.logger.Warn("Delivery failed, retrying", "error", err, "attempt", attempt, "backoff", wait.String())
+		select {
+		case <-time.After(wait):
+		case <-deliveryCtx.Done():
+			return deliveryCtx.Err()
+		}
+		backoff *= 2
+	}
+
+	// Deliveries canceled by shutdown say nothing about the webhook's health
+	if !errors.Is(err, context.Canceled) {
+		breaker.record(job.webhookURL, err, time.Now())
+	}
+	return err
+}
+
+// recordAudit logs the delivery outcome for every request in the job
+func (job deliveryJob) recordAudit(err error) {
+	result := "success"
+	if err != nil {
+		result = "failure"
+	}
+	for _, entry := range job.audit {
+		audit.record(entry, result, err)
+	}
+}
+
+// sendNotifications delivers a batch in as few messages as the notifier supports
+func sendNotifications(ctx context.Context, notifier Notifier, messages []Notification) error {
+	if batcher, ok := notifier.(BatchNotifier); ok && len(messages) > 1 {
+		return batcher.SendBatch(ctx, messages)
+	}
+
+	var errs []error
+	for _, message := range messages {
+		if err := notifier.Send(ctx, message); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// queueDelivery batches a job when batching is enabled, otherwise queues it directly
+func queueDelivery(job deliveryJob) {
+	if batches != nil && job.batchKey != "" {
+		batches.add(job.batchKey, job)
+		return
+	}
+	enqueueDelivery(job)
+}
+
+// enqueueDelivery queues a job, applying backpressure when the queue is full.
+// It reports whether the job was accepted.
+func enqueueDelivery(job deliveryJob) bool {
+	deliveryQueueMu.RLock()
+	defer deliveryQueueMu.RUnlock()
+	if deliveryQueueClosed {
+		job.logger.Warn("Delivery queue closed, dropping notification")
+		job.recordDropped()
+		return false
+	}
+
+	select {
+	case deliveryQueue <- job:
+		return true
+	default:
+	}
+
+	timer := time.NewTimer(enqueueTimeout)
+	defer timer.Stop()
+	select {
+	case deliveryQueue <- job:
+		return true
+	case <-timer.C:
+		job.logger.Warn("Delivery queue full, dropping notification", "queue_size", cap(deliveryQueue))
+		job.recordDropped()
+		return false
+	}
+}
+
+// recordDropped audits every request in a job that was never queued
+func (job deliveryJob) recordDropped() {
+	for _, entry := range job.audit {
+		audit.record(entry, "dropped", nil)
+	}
+}
+
+// stopDeliveryWorkers closes the queue and waits for queued jobs to be
+// flushed, after which late jobs are dropped
+func stopDeliveryWorkers(ctx context.Context) error {
+	deliveryQueueMu.Lock()
+	deliveryQueueClosed = true
+	close(deliveryQueue)
+	deliveryQueueMu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		deliveryWorkers.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		cancelDeliveries()
+		return ctx.Err()
+	}
+}
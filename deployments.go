@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+)
+
+// GitHub deployment status payload structure
+type DeploymentStatus struct {
+	State       string `json:"state"`
+	Environment string `json:"environment"`
+	TargetURL   string `json:"target_url"`
+	Description string `json:"description"`
+}
+
+func (s *Server) handleDeploymentStatusEvent(event GitHubEvent) *Notification {
+	status := event.DeploymentStatus
+	slog.Info("Processing deployment status event", "state", status.State, "environment", status.Environment)
+
+	// Only announce finished deployments
+	if status.State != "success" && status.State != "failure" {
+		slog.Info("Ignoring deployment state", "state", status.State)
+		return nil
+	}
+
+	// Create the notification
+	message := Notification{
+		Title: fmt.Sprintf("Deploy to %s: %s", status.Environment, status.State),
+		Description: fmt.Sprintf("Deployment of [%s](%s) to **%s** %s",
+			event.Repository.FullName,
+			event.Repository.HTMLURL,
+			escapeMarkdown(status.Environment),
+			status.State),
+		Color: colorFor("deploy", status.State),
+		URL:   status.TargetURL,
+		Fields: []NotificationField{
+			{
+				Name:   "Repository",
+				Value:  fmt.Sprintf("[%s](%s)", event.Repository.FullName, event.Repository.HTMLURL),
+				Inline: true,
+			},
+			{
+				Name:   "Triggered by",
+				Value:  fmt.Sprintf("[%s](%s)", event.Sender.Login, event.Sender.HTMLURL),
+				Inline: true,
+			},
+		},
+		Username:  s.ciIdentity.Username,
+		AvatarURL: s.ciIdentity.AvatarURL,
+	}
+
+	if status.Description != "" {
+		message.Fields = append(message.Fields, NotificationField{
+			Name:  "Details",
+			Value: escapeMarkdown(status.Description),
+		})
+	}
+
+	return &message
+}
@@ -0,0 +1,466 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Discord message structures
+type DiscordEmbed struct {
+	Title       string              `json:"title"`
+	Description string              `json:"description"`
+	Color       int                 `json:"color"`
+	URL         string              `json:"url,omitempty"`
+	Timestamp   string              `json:"timestamp,omitempty"`
+	Footer      *DiscordEmbedFooter `json:"footer,omitempty"`
+	Author      *DiscordEmbedAuthor `json:"author,omitempty"`
+	Fields      []DiscordEmbedField `json:"fields,omitempty"`
+}
+
+type DiscordEmbedAuthor struct {
+	Name    string `json:"name"`
+	URL     string `json:"url,omitempty"`
+	IconURL string `json:"icon_url,omitempty"`
+}
+
+type DiscordEmbedFooter struct {
+	Text string `json:"text"`
+}
+
+type DiscordEmbedField struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Inline bool   `json:"inline,omitempty"`
+}
+
+type DiscordMessage struct {
+	Content         string           `json:"content,omitempty"`
+	Username        string           `json:"username,omitempty"`
+	AvatarURL       string           `json:"avatar_url,omitempty"`
+	Embeds          []DiscordEmbed   `json:"embeds,omitempty"`
+	AllowedMentions *AllowedMentions `json:"allowed_mentions,omitempty"`
+
+	// Creates a post with this name on forum channel webhooks, which require it
+	ThreadName string `json:"thread_name,omitempty"`
+}
+
+type AllowedMentions struct {
+	Parse []string `json:"parse"`
+	Roles []string `json:"roles,omitempty"`
+}
+
+// Discord embed size limits
+const (
+	maxEmbedTitleLength       = 256
+	maxEmbedDescriptionLength = 4096
+	maxEmbedFieldNameLength   = 256
+	maxEmbedFieldValueLength  = 1024
+	maxEmbedAuthorNameLength  = 256
+)
+
+// Discord's limit on forum thread names
+const maxThreadNameLength = 100
+
+// Discord's limit on embeds in a single message
+const maxEmbedsPerMessage = 10
+
+// Embeds combined into one message when batching, at most maxEmbedsPerMessage
+var embedsPerMessage = maxEmbedsPerMessage
+
+// HTTP client used for all Discord requests, deadlines come from the request context
+var discordClient = &http.Client{}
+
+// Maximum total time spent waiting on Discord rate limits for a single message
+const maxRateLimitWait = 30 * time.Second
+
+// Returned when Discord asks us to wait longer than maxRateLimitWait,
+// retrying would only run into the same limit
+var errRateLimitTooLong = errors.New("Discord rate limit wait exceeds limit, dropping message")
+
+// DiscordNotifier delivers notifications as embeds to a Discord webhook
+type DiscordNotifier struct {
+	WebhookURL string
+}
+
+func (d DiscordNotifier) Send(ctx context.Context, message Notification) error {
+	discordMessage := discordMessageFor(message)
+	if message.MessageKey == "" || dryRun {
+		return sendDiscordMessage(ctx, d.WebhookURL, discordMessage)
+	}
+
+	// Update the tracked message in place, posting a new one if that isn't possible
+	key := d.WebhookURL + "|" + message.MessageKey
+	if message.UpdateExisting {
+		if id, ok := trackedMessages.take(key); ok {
+			edit := discordMessage
+			edit.ThreadName = "" // Only valid when creating a post
+			err := editDiscordMessage(ctx, d.WebhookURL, id, edit)
+			if err == nil {
+				return nil
+			}
+			slog.Warn("Error editing Discord message, posting a new one", "message_id", id, "error", err)
+		}
+		return sendDiscordMessage(ctx, d.WebhookURL, discordMessage)
+	}
+
+	// Remember the message ID so later notifications can update it
+	id, err := sendTrackedDiscordMessage(ctx, d.WebhookURL, discordMessage)
+	if err != nil {
+		return err
+	}
+	trackedMessages.store(key, id)
+	return nil
+}
+
+// SendBatch delivers several notifications as the embeds of as few messages as possible
+func (d DiscordNotifier) SendBatch(ctx context.Context, messages []Notification) error {
+	var errs []error
+	for _, message := range batchDiscordMessages(messages, embedsPerMessage) {
+		if err := sendDiscordMessage(ctx, d.WebhookURL, message); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// batchDiscordMessages combines notifications into messages of at most max embeds each
+func batchDiscordMessages(messages []Notification, max int) []DiscordMessage {
+	var batched []DiscordMessage
+	for start := 0; start < len(messages); start += max {
+		end := min(start+max, len(messages))
+		batched = append(batched, mergeDiscordMessages(messages[start:end]))
+	}
+	return batched
+}
+
+// mergeDiscordMessages builds one Discord message holding the embeds of every notification
+func mergeDiscordMessages(messages []Notification) DiscordMessage {
+	var merged DiscordMessage
+	var contents []string
+	for i, n := range messages {
+		message := discordMessageFor(n)
+		if i == 0 {
+			merged.Username, merged.AvatarURL = message.Username, message.AvatarURL
+			merged.ThreadName = message.ThreadName
+		}
+		if message.Content != "" && !contains(contents, message.Content) {
+			contents = append(contents, message.Content)
+		}
+		merged.Embeds = append(merged.Embeds, message.Embeds...)
+
+		// Keep every mention the individual messages were allowed to ping
+		if message.AllowedMentions != nil {
+			if merged.AllowedMentions == nil {
+				merged.AllowedMentions = &AllowedMentions{Parse: []string{}}
+			}
+			for _, parse := range message.AllowedMentions.Parse {
+				if !contains(merged.AllowedMentions.Parse, parse) {
+					merged.AllowedMentions.Parse = append(merged.AllowedMentions.Parse, parse)
+				}
+			}
+			for _, role := range message.AllowedMentions.Roles {
+				if !contains(merged.AllowedMentions.Roles, role) {
+					merged.AllowedMentions.Roles = append(merged.AllowedMentions.Roles, role)
+				}
+			}
+		}
+	}
+	merged.Content = strings.Join(contents, "\n")
+	return merged
+}
+
+// How long a tracked message can still be edited, so PRs that are never
+// merged or closed don't keep their entry forever
+const trackedMessageTTL = 30 * 24 * time.Hour
+
+// messageStore tracks Discord message IDs that may be edited later
+type messageStore struct {
+	mu  sync.Mutex
+	ttl time.Duration
+	ids map[string]trackedMessage
+}
+
+type trackedMessage struct {
+	id       string
+	storedAt time.Time
+}
+
+// Discord message IDs keyed by webhook URL and notification message key
+var trackedMessages = &messageStore{ttl: trackedMessageTTL, ids: make(map[string]trackedMessage)}
+
+func (ms *messageStore) store(key, id string) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	// Evict messages too old to be edited
+	now := time.Now()
+	for k, message := range ms.ids {
+		if now.Sub(message.storedAt) > ms.ttl {
+			delete(ms.ids, k)
+		}
+	}
+	ms.ids[key] = trackedMessage{id: id, storedAt: now}
+}
+
+// take returns and forgets the message ID stored for key
+func (ms *messageStore) take(key string) (string, bool) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	message, ok := ms.ids[key]
+	delete(ms.ids, key)
+	if !ok || time.Since(message.storedAt) > ms.ttl {
+		return "", false
+	}
+	return message.id, true
+}
+
+// forget drops the message IDs stored for a message key on every webhook
+func (ms *messageStore) forget(messageKey string) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	for key := range ms.ids {
+		if strings.HasSuffix(key, "|"+messageKey) {
+			delete(ms.ids, key)
+		}
+	}
+}
+
+// discordMessageFor translates a notification into a single-embed Discord message
+func discordMessageFor(n Notification) DiscordMessage {
+	embed := DiscordEmbed{
+		Title:       truncate(n.Title, maxEmbedTitleLength),
+		Description: truncate(n.Description, maxEmbedDescriptionLength),
+		Color:       n.Color,
+		URL:         n.URL,
+	}
+	if !n.Timestamp.IsZero() {
+		embed.Timestamp = n.Timestamp.UTC().Format(time.RFC3339)
+	}
+	if n.Footer != "" {
+		embed.Footer = &DiscordEmbedFooter{Text: n.Footer}
+	}
+	if n.Author != nil {
+		embed.Author = &DiscordEmbedAuthor{
+			Name:    truncate(n.Author.Name, maxEmbedAuthorNameLength),
+			URL:     n.Author.URL,
+			IconURL: n.Author.IconURL,
+		}
+	}
+	for _, field := range n.Fields {
+		embed.Fields = append(embed.Fields, DiscordEmbedField{
+			Name:   truncate(field.Name, maxEmbedFieldNameLength),
+			Value:  truncate(field.Value, maxEmbedFieldValueLength),
+			Inline: field.Inline,
+		})
+	}
+
+	message := DiscordMessage{
+		Content:   n.Content,
+		Username:  n.Username,
+		AvatarURL: n.AvatarURL,
+		Embeds:    []DiscordEmbed{embed},
+	}
+	if n.ThreadName != "" {
+		message.ThreadName = truncate(n.ThreadName, maxThreadNameLength)
+	}
+
+	// Mentions only ping when explicitly allowed
+	if n.Mention != "" {
+		mention := n.Mention
+		allowed := &AllowedMentions{Parse: []string{}}
+		switch n.Mention {
+		case "@here", "@everyone":
+			allowed.Parse = []string{"everyone"}
+		default:
+			mention = fmt.Sprintf("<@&%s>", n.Mention)
+			allowed.Roles = []string{n.Mention}
+		}
+		message.Content = strings.TrimSpace(mention + " " + message.Content)
+		message.AllowedMentions = allowed
+	}
+
+	return message
+}
+
+// Text around every Discord message's content, labeling the instance
+// (e.g. "[STAGING]") when several post to the same server
+var messagePrefix, messageSuffix string
+
+// withAffixes surrounds the content with MESSAGE_PREFIX and MESSAGE_SUFFIX
+func withAffixes(message DiscordMessage) DiscordMessage {
+	message.Content = strings.TrimSpace(strings.Join([]string{messagePrefix, message.Content, messageSuffix}, " "))
+	return message
+}
+
+func sendDiscordMessage(ctx context.Context, webhookURL string, message DiscordMessage) error {
+	// Log the payload instead of sending it in dry-run mode
+	if dryRun {
+		return logDryRun("discord", withAffixes(message))
+	}
+
+	_, err := discordRequest(ctx, http.MethodPost, webhookURL, message)
+	return err
+}
+
+// sendTrackedDiscordMessage posts a message and returns the ID Discord assigned to it
+func sendTrackedDiscordMessage(ctx context.Context, webhookURL string, message DiscordMessage) (string, error) {
+	// Discord only returns the created message when asked to wait for it
+	waitURL, err := url.Parse(webhookURL)
+	if err != nil {
+		return "", fmt.Errorf("parsing webhook URL: %w", err)
+	}
+	query := waitURL.Query()
+	query.Set("wait", "true")
+	waitURL.RawQuery = query.Encode()
+
+	body, err := discordRequest(ctx, http.MethodPost, waitURL.String(), message)
+	if err != nil {
+		return "", err
+	}
+
+	var created struct {
+		ID string `json:"id"`
+	}
+	if err := json.Unmarshal(body, &created); err != nil || created.ID == "" {
+		return "", fmt.Errorf("reading Discord message ID: %s", string(body))
+	}
+	return created.ID, nil
+}
+
+// editDiscordMessage replaces the content of a message previously sent through the webhook
+func editDiscordMessage(ctx context.Context, webhookURL, messageID string, message DiscordMessage) error {
+	editURL, err := url.Parse(webhookURL)
+	if err != nil {
+		return fmt.Errorf("parsing webhook URL: %w", err)
+	}
+	editURL.Path = path.Join(editURL.Path, "messages", messageID)
+
+	_, err = discordRequest(ctx, http.MethodPatch, editURL.String(), message)
+	return err
+}
+
+// discordRequest sends a message to a Discord webhook endpoint, waiting out
+// rate limits, and returns the response body
+func discordRequest(ctx context.Context, method, requestURL string, message DiscordMessage) (body []byte, err error) {
+	// Record the outcome of every delivery
+	defer func() {
+		result := "success"
+		if err != nil {
+			result = "failure"
+		}
+		discordDeliveries.WithLabelValues(result).Inc()
+	}()
+
+	// Convert message to JSON
+	jsonData, err := json.Marshal(withAffixes(message))
+	if err != nil {
+		return nil, fmt.Errorf("marshaling Discord message: %w", err)
+	}
+
+	var waited time.Duration
+	for {
+		reqCtx, cancel := requestContext(ctx)
+		req, err := http.NewRequestWithContext(reqCtx, method, requestURL, bytes.NewBuffer(jsonData))
+		if err != nil {
+			cancel()
+			return nil, fmt.Errorf("creating Discord request: %w", err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+
+		// Send the request to the Discord webhook
+		start := time.Now()
+		resp, err := discordClient.Do(req)
+		discordRequestDuration.Observe(time.Since(start).Seconds())
+		if err != nil {
+			cancel()
+			err = redactError(err)
+			if isTimeout(err) {
+				return nil, fmt.Errorf("Discord request timed out: %w", err)
+			}
+			return nil, fmt.Errorf("sending Discord message: %w", err)
+		}
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		cancel()
+
+		// Wait and re-send the same payload when Discord rate limits us
+		if resp.StatusCode == http.StatusTooManyRequests {
+			wait := retryAfter(resp.Header, bodyBytes)
+			if waited+wait > maxRateLimitWait {
+				return nil, fmt.Errorf("%w: waiting %s", errRateLimitTooLong, waited+wait)
+			}
+			slog.Warn("Discord rate limited, retrying", "discord_status", resp.StatusCode, "retry_after", wait.String())
+			select {
+			case <-time.After(wait):
+			case <-ctx.Done():
+				return nil, fmt.Errorf("waiting out Discord rate limit: %w", ctx.Err())
+			}
+			waited += wait
+			continue
+		}
+
+		// Check response status
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			return nil, &discordAPIError{StatusCode: resp.StatusCode, Body: string(bodyBytes)}
+		}
+
+		slog.Info("Discord message sent successfully", "discord_status", resp.StatusCode)
+		return bodyBytes, nil
+	}
+}
+
+// retryAfter determines how long to wait after a 429 response, preferring the
+// retry_after value in the JSON body and falling back to the Retry-After header
+func retryAfter(header http.Header, body []byte) time.Duration {
+	var rateLimit struct {
+		RetryAfter float64 `json:"retry_after"`
+	}
+	if err := json.Unmarshal(body, &rateLimit); err == nil && rateLimit.RetryAfter > 0 {
+		return time.Duration(rateLimit.RetryAfter * float64(time.Second))
+	}
+
+	if seconds, err := strconv.ParseFloat(header.Get("Retry-After"), 64); err == nil && seconds > 0 {
+		return time.Duration(seconds * float64(time.Second))
+	}
+
+	return time.Second // Default wait when Discord gives no hint
+}
+
+// discordAPIError is returned when Discord responds with a non-2xx status
+type discordAPIError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *discordAPIError) Error() string {
+	return fmt.Sprintf("Discord API error (status %d): %s", e.StatusCode, e.Body)
+}
+
+// discordStatusAttr returns the discord_status log attribute for an API error,
+// or an empty attribute which slog ignores
+func discordStatusAttr(err error) slog.Attr {
+	var apiErr *discordAPIError
+	if errors.As(err, &apiErr) {
+		return slog.Int("discord_status", apiErr.StatusCode)
+	}
+	return slog.Attr{}
+}
+
+// isTimeout reports whether err was caused by a request timeout
+func isTimeout(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
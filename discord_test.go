@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestDiscordRequestRetriesAfterRateLimit(t *testing.T) {
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) == 1 {
+			w.WriteHeader(http.StatusTooManyRequests)
+			fmt.Fprint(w, `{"retry_after":0.01}`)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	if _, err := discordRequest(context.Background(), http.MethodPost, srv.URL, DiscordMessage{Content: "hello"}); err != nil {
+		t.Fatalf("discordRequest: %v", err)
+	}
+	if got := requests.Load(); got != 2 {
+		t.Errorf("server saw %d requests, want 2", got)
+	}
+}
+
+func TestDiscordRequestTimeoutExcludesRateLimitWait(t *testing.T) {
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) == 1 {
+			w.WriteHeader(http.StatusTooManyRequests)
+			fmt.Fprint(w, `{"retry_after":0.2}`)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	// Each request is fast, only the wait between them outlasts the timeout
+	ctx := withRequestTimeout(context.Background(), 100*time.Millisecond)
+	if _, err := discordRequest(ctx, http.MethodPost, srv.URL, DiscordMessage{Content: "hello"}); err != nil {
+		t.Fatalf("discordRequest: %v", err)
+	}
+	if got := requests.Load(); got != 2 {
+		t.Errorf("server saw %d requests, want 2", got)
+	}
+}
+
+func TestDiscordRequestRateLimitTooLong(t *testing.T) {
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusTooManyRequests)
+		fmt.Fprintf(w, `{"retry_after":%g}`, (maxRateLimitWait + time.Second).Seconds())
+	}))
+	defer srv.Close()
+
+	start := time.Now()
+	_, err := discordRequest(context.Background(), http.MethodPost, srv.URL, DiscordMessage{Content: "hello"})
+	if !errors.Is(err, errRateLimitTooLong) {
+		t.Fatalf("discordRequest error = %v, want errRateLimitTooLong", err)
+	}
+	if isRetryable(err) {
+		t.Error("rate limit over the cap is retryable, want it dropped")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("discordRequest took %s, want it to give up without waiting", elapsed)
+	}
+	if got := requests.Load(); got != 1 {
+		t.Errorf("server saw %d requests, want 1", got)
+	}
+}
+
+// testNotifications builds n distinct notifications
+func testNotifications(n int) []Notification {
+	messages := make([]Notification, n)
+	for i := range messages {
+		messages[i] = Notification{Title: fmt.Sprintf("Notification %d", i+1)}
+	}
+	return messages
+}
+
+func TestBatchDiscordMessages(t *testing.T) {
+	tests := []struct {
+		name       string
+		count      int
+		max        int
+		wantEmbeds []int
+	}{
+		{name: "discord limit", count: 23, max: maxEmbedsPerMessage, wantEmbeds: []int{10, 10, 3}},
+		{name: "lowered limit", count: 23, max: 5, wantEmbeds: []int{5, 5, 5, 5, 3}},
+		{name: "single batch", count: 4, max: maxEmbedsPerMessage, wantEmbeds: []int{4}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			messages := testNotifications(tt.count)
+			batched := batchDiscordMessages(messages, tt.max)
+			if len(batched) != len(tt.wantEmbeds) {
+				t.Fatalf("got %d messages, want %d", len(batched), len(tt.wantEmbeds))
+			}
+
+			next := 0
+			for i, message := range batched {
+				if len(message.Embeds) != tt.wantEmbeds[i] {
+					t.Errorf("message %d has %d embeds, want %d", i+1, len(message.Embeds), tt.wantEmbeds[i])
+				}
+				// Notifications keep their order across messages
+				for _, embed := range message.Embeds {
+					if embed.Title != messages[next].Title {
+						t.Errorf("embed %q out of order, want %q", embed.Title, messages[next].Title)
+					}
+					next++
+				}
+			}
+		})
+	}
+}
+
+func TestSendBatchHonorsMaxEmbedsPerMessage(t *testing.T) {
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	// As set by MAX_EMBEDS_PER_MESSAGE=5
+	defer func(previous int) { embedsPerMessage = previous }(embedsPerMessage)
+	embedsPerMessage = 5
+
+	notifier := DiscordNotifier{WebhookURL: srv.URL + "/api/webhooks/1/batch"}
+	if err := notifier.SendBatch(context.Background(), testNotifications(23)); err != nil {
+		t.Fatalf("SendBatch: %v", err)
+	}
+	if got := requests.Load(); got != 5 {
+		t.Errorf("server saw %d requests, want 5", got)
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+)
+
+// GitHub discussion payload structures
+type Discussion struct {
+	Number   int                `json:"number"`
+	Title    string             `json:"title"`
+	HTMLURL  string             `json:"html_url"`
+	Body     string             `json:"body"`
+	Category DiscussionCategory `json:"category"`
+}
+
+type DiscussionCategory struct {
+	Name string `json:"name"`
+}
+
+func (s *Server) handleDiscussionEvent(event GitHubEvent) *Notification {
+	slog.Info("Processing discussion event", "action", event.Action)
+
+	// Only announce new discussions
+	if event.Action != "created" {
+		slog.Info("Ignoring discussion action", "action", event.Action)
+		return nil
+	}
+
+	// Create the notification
+	message := Notification{
+		Title: "New discussion",
+		Description: fmt.Sprintf("**%s** started [#%d: %s](%s)",
+			event.Sender.Login,
+			event.Discussion.Number,
+			escapeMarkdown(event.Discussion.Title),
+			event.Discussion.HTMLURL),
+		Color: colorFor("discussion", event.Action),
+		URL:   event.Discussion.HTMLURL,
+		Fields: []NotificationField{
+			{
+				Name:   "Repository",
+				Value:  fmt.Sprintf("[%s](%s)", event.Repository.FullName, event.Repository.HTMLURL),
+				Inline: true,
+			},
+			{
+				Name:   "Category",
+				Value:  escapeMarkdown(event.Discussion.Category.Name),
+				Inline: true,
+			},
+		},
+	}
+
+	return &message
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"os"
+	"strings"
+)
+
+// Default title emoji keyed by outcome or event type, overridable with EMOJI_<KEY>
+var defaultEmoji = map[string]string{
+	"success":   "✅",
+	"failure":   "❌",
+	"timed_out": "❌",
+	"merged":    "🔀",
+	"release":   "📦",
+}
+
+// emojiFor returns the title emoji for an event outcome, preferring env var
+// overrides and the outcome over the event type
+func emojiFor(event, outcome string) string {
+	for _, key := range []string{outcome, event} {
+		if key == "" {
+			continue
+		}
+		if value, ok := os.LookupEnv("EMOJI_" + strings.ToUpper(key)); ok {
+			return value
+		}
+		if emoji, ok := defaultEmoji[key]; ok {
+			return emoji
+		}
+	}
+	return ""
+}
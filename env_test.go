@@ -0,0 +1,42 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEnvBool(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    bool
+		wantErr bool
+	}{
+		{value: "", want: false},
+		{value: "true", want: true},
+		{value: "1", want: true},
+		{value: "false", want: false},
+		{value: "yes", wantErr: true},
+		{value: "on", wantErr: true},
+		{value: "1x", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Setenv("TEST_FLAG", tt.value)
+		got, err := envBool("TEST_FLAG")
+		if (err != nil) != tt.wantErr {
+			t.Errorf("envBool(%q) error = %v, want error %v", tt.value, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("envBool(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestLoadConfigRejectsInvalidBool(t *testing.T) {
+	t.Setenv("EMOJI_ENABLED", "on")
+	_, err := loadConfig()
+	if err == nil || !strings.Contains(err.Error(), "EMOJI_ENABLED") {
+		t.Fatalf("loadConfig error = %v, want one naming EMOJI_ENABLED", err)
+	}
+}
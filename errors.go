@@ -0,0 +1,29 @@
+package main
+
+import "github.com/gin-gonic/gin"
+
+// errorResponse is the body of every error returned to webhook senders, with
+// a stable code monitoring can match on
+type errorResponse struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Error codes returned by the webhook endpoint
+const (
+	errCodeUnsupportedMediaType = "unsupported_media_type" // 415, neither JSON nor form-encoded
+	errCodePayloadTooLarge      = "payload_too_large"      // 413, body over MAX_BODY_BYTES
+	errCodeUnreadableBody       = "unreadable_body"        // 400, body couldn't be read
+	errCodeInvalidGzip          = "invalid_gzip"           // 400, Content-Encoding gzip with a corrupt stream
+	errCodeInvalidSignature     = "invalid_signature"      // 401, signature missing or wrong
+	errCodeMissingPayload       = "missing_payload"        // 400, form without a payload value
+	errCodeInvalidJSON          = "invalid_json"           // 400, payload isn't valid JSON
+	errCodeMissingField         = "missing_field"          // 422, payload lacks a field the event requires
+	errCodeRateLimited          = "rate_limited"           // 429, client over RATE_LIMIT_RPS
+	errCodeForbiddenIP          = "forbidden_ip"           // 403, client outside the IP allowlist
+)
+
+// abortWithError ends the request with a coded error body
+func abortWithError(c *gin.Context, status int, code, message string) {
+	c.AbortWithStatusJSON(status, errorResponse{Code: code, Message: message})
+}
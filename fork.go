@@ -0,0 +1,41 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"strconv"
+)
+
+// GitHub fork payload structure, the repository created by the fork
+type Forkee struct {
+	FullName string `json:"full_name"`
+	HTMLURL  string `json:"html_url"`
+}
+
+func (s *Server) handleForkEvent(event GitHubEvent) *Notification {
+	slog.Info("Processing fork event", "forkee", event.Forkee.FullName)
+
+	// Create the notification
+	message := Notification{
+		Title: "New fork",
+		Description: fmt.Sprintf("🍴 **%s** forked [%s](%s) → [%s](%s)",
+			event.Sender.Login,
+			event.Repository.FullName,
+			event.Repository.HTMLURL,
+			event.Forkee.FullName,
+			event.Forkee.HTMLURL),
+		Color: colorFor("fork", "created"),
+		URL:   event.Forkee.HTMLURL,
+	}
+
+	// Include the new fork count when GitHub sends it
+	if event.Repository.ForksCount > 0 {
+		message.Fields = append(message.Fields, NotificationField{
+			Name:   "Forks",
+			Value:  strconv.Itoa(event.Repository.ForksCount),
+			Inline: true,
+		})
+	}
+
+	return &message
+}
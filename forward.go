@@ -0,0 +1,47 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// HTTP client used to relay raw payloads
+var forwardClient = &http.Client{Timeout: 10 * time.Second}
+
+// Headers copied from GitHub's request when relaying a payload
+var forwardedHeaders = []string{
+	"Content-Type",
+	"X-GitHub-Event",
+	"X-GitHub-Delivery",
+	"X-Hub-Signature",
+	"X-Hub-Signature-256",
+}
+
+// forwardPayload relays an untouched webhook delivery to another endpoint
+func forwardPayload(forwardURL string, header http.Header, body []byte) error {
+	req, err := http.NewRequest(http.MethodPost, forwardURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("creating forward request: %w", err)
+	}
+	for _, name := range forwardedHeaders {
+		if value := header.Get(name); value != "" {
+			req.Header.Set(name, value)
+		}
+	}
+	signBridgePayload(req.Header, body)
+
+	resp, err := forwardClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("forwarding payload: %w", redactError(err))
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("forward endpoint returned status %d", resp.StatusCode)
+	}
+	slog.Debug("Forwarded webhook payload", "status", resp.StatusCode)
+	return nil
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Build version, set with -ldflags "-X main.version=v1.2.3"
+var version = "dev"
+
+// When the process started, reported as uptime by /health
+var startTime = time.Now()
+
+// Events handled since start, reported by /health
+var (
+	eventsReceived atomic.Int64
+	eventsNotified atomic.Int64
+)
+
+// handleHealth reports liveness along with details that help spot restarts
+// and confirm which build is deployed
+func handleHealth(c *gin.Context) {
+	c.JSON(200, gin.H{
+		"status":         "ok",
+		"version":        version,
+		"uptime":         time.Since(startTime).Round(time.Second).String(),
+		"uptime_seconds": int64(time.Since(startTime).Seconds()),
+		"events": gin.H{
+			"received": eventsReceived.Load(),
+			"notified": eventsNotified.Load(),
+		},
+	})
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+)
+
+// Maximum number of repositories listed for an installation change
+const maxInstallationRepos = 10
+
+// GitHub App installation payload structures
+type Installation struct {
+	ID      int64  `json:"id"`
+	Account Sender `json:"account"`
+	HTMLURL string `json:"html_url"`
+}
+
+type InstallationRepository struct {
+	FullName string `json:"full_name"`
+}
+
+func (s *Server) handleInstallationEvent(event GitHubEvent) *Notification {
+	slog.Info("Processing installation event", "action", event.Action)
+
+	// Only installs and uninstalls are sent
+	if event.Action != "created" && event.Action != "deleted" {
+		slog.Info("Ignoring installation action", "action", event.Action)
+		return nil
+	}
+
+	title, verb := "App installed", "installed the app on"
+	if event.Action == "deleted" {
+		title, verb = "App uninstalled", "uninstalled the app from"
+	}
+
+	// Create the notification
+	message := Notification{
+		Title: title,
+		Description: fmt.Sprintf("**%s** %s **%s**",
+			event.Sender.Login,
+			verb,
+			event.Installation.Account.Login),
+		Color: colorFor("installation", event.Action),
+		URL:   event.Installation.HTMLURL,
+		Fields: []NotificationField{
+			{
+				Name:   "Account",
+				Value:  fmt.Sprintf("[%s](%s)", event.Installation.Account.Login, event.Installation.Account.HTMLURL),
+				Inline: true,
+			},
+		},
+	}
+
+	if repos := event.Repositories; len(repos) > 0 {
+		message.Fields = append(message.Fields, NotificationField{
+			Name:  "Repositories",
+			Value: formatInstallationRepos(repos),
+		})
+	}
+
+	return &message
+}
+
+func (s *Server) handleInstallationRepositoriesEvent(event GitHubEvent) *Notification {
+	slog.Info("Processing installation repositories event", "action", event.Action)
+
+	// Repositories are either added to or removed from the installation
+	repos, verb := event.RepositoriesAdded, "added"
+	switch event.Action {
+	case "added":
+	case "removed":
+		repos, verb = event.RepositoriesRemoved, "removed"
+	default:
+		slog.Info("Ignoring installation repositories action", "action", event.Action)
+		return nil
+	}
+
+	// Create the notification
+	message := Notification{
+		Title: fmt.Sprintf("App repositories %s", verb),
+		Description: fmt.Sprintf("**%s** %s %d repositories for **%s**",
+			event.Sender.Login,
+			verb,
+			len(repos),
+			event.Installation.Account.Login),
+		Color: colorFor("installation", verb),
+		URL:   event.Installation.HTMLURL,
+	}
+
+	if len(repos) > 0 {
+		message.Fields = append(message.Fields, NotificationField{
+			Name:  "Repositories",
+			Value: formatInstallationRepos(repos),
+		})
+	}
+
+	return &message
+}
+
+// formatInstallationRepos lists repository names, summarizing any past maxInstallationRepos
+func formatInstallationRepos(repos []InstallationRepository) string {
+	var names []string
+	for i, repo := range repos {
+		if i == maxInstallationRepos {
+			names = append(names, fmt.Sprintf("+%d more", len(repos)-maxInstallationRepos))
+			break
+		}
+		names = append(names, escapeMarkdown(repo.FullName))
+	}
+	return strings.Join(names, "\n")
+}
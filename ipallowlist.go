@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/netip"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GitHub API endpoint publishing the source ranges of webhook deliveries
+const githubMetaURL = "https://api.github.com/meta"
+
+// How long fetching the webhook ranges may take at startup
+const githubMetaTimeout = 10 * time.Second
+
+// parseCIDRs parses a list of CIDR ranges such as "192.30.252.0/22"
+func parseCIDRs(values []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(values))
+	for _, value := range values {
+		prefix, err := netip.ParsePrefix(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CIDR %q: %w", value, err)
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	return prefixes, nil
+}
+
+// fetchGitHubHookCIDRs reads the webhook source ranges from GitHub's meta API
+func fetchGitHubHookCIDRs() ([]netip.Prefix, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), githubMetaTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, githubMetaURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating meta request: %w", err)
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetching GitHub meta: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GitHub meta API error (status %d)", resp.StatusCode)
+	}
+
+	var meta struct {
+		Hooks []string `json:"hooks"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&meta); err != nil {
+		return nil, fmt.Errorf("parsing GitHub meta: %w", err)
+	}
+	if len(meta.Hooks) == 0 {
+		return nil, errors.New("GitHub meta lists no hook ranges")
+	}
+	return parseCIDRs(meta.Hooks)
+}
+
+// ipAllowlist rejects requests whose client IP is outside the allowed ranges,
+// except on the exempt paths
+func ipAllowlist(prefixes []netip.Prefix, exempt ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if contains(exempt, c.Request.URL.Path) {
+			c.Next()
+			return
+		}
+
+		ip, err := netip.ParseAddr(c.ClientIP())
+		if err == nil {
+			ip = ip.Unmap()
+			for _, prefix := range prefixes {
+				if prefix.Contains(ip) {
+					c.Next()
+					return
+				}
+			}
+		}
+
+		slog.Warn("Rejected request from IP outside the allowlist", "client_ip", c.ClientIP(), "path", c.Request.URL.Path)
+		abortWithError(c, 403, errCodeForbiddenIP, "Forbidden")
+	}
+}
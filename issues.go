@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+)
+
+// GitHub issue payload structures
+type Issue struct {
+	Number  int     `json:"number"`
+	Title   string  `json:"title"`
+	HTMLURL string  `json:"html_url"`
+	State   string  `json:"state"`
+	Body    string  `json:"body"`
+	Labels  []Label `json:"labels"`
+
+	// Set when the issue is a pull request
+	PullRequest *IssuePullRequest `json:"pull_request"`
+}
+
+type IssuePullRequest struct {
+	HTMLURL string `json:"html_url"`
+}
+
+type Label struct {
+	Name string `json:"name"`
+}
+
+func (s *Server) handleIssuesEvent(event GitHubEvent) *Notification {
+	slog.Info("Processing issues event", "action", event.Action)
+
+	// We only want to handle specific actions
+	if event.Action != "opened" && event.Action != "reopened" && event.Action != "closed" {
+		slog.Info("Ignoring issue action", "action", event.Action)
+		return nil
+	}
+
+	// Create the notification
+	message := Notification{
+		Title: fmt.Sprintf("Issue %s", event.Action),
+		Description: fmt.Sprintf("**%s** %s [#%d: %s](%s)",
+			event.Sender.Login,
+			event.Action,
+			event.Issue.Number,
+			escapeMarkdown(event.Issue.Title),
+			event.Issue.HTMLURL),
+		Color: colorFor("issue", event.Action),
+		URL:   event.Issue.HTMLURL,
+		Fields: []NotificationField{
+			{
+				Name:   "Repository",
+				Value:  fmt.Sprintf("[%s](%s)", event.Repository.FullName, event.Repository.HTMLURL),
+				Inline: true,
+			},
+		},
+	}
+
+	// List the issue labels when there are any
+	if len(event.Issue.Labels) > 0 {
+		names := make([]string, len(event.Issue.Labels))
+		for i, label := range event.Issue.Labels {
+			names[i] = escapeMarkdown(label.Name)
+		}
+		message.Fields = append(message.Fields, NotificationField{
+			Name:   "Labels",
+			Value:  strings.Join(names, ", "),
+			Inline: true,
+		})
+	}
+
+	return &message
+}
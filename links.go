@@ -0,0 +1,58 @@
+package main
+
+import (
+	"net/url"
+	"strings"
+)
+
+// parseBaseURL validates GITHUB_BASE_URL and returns its host
+func parseBaseURL(raw string) (string, error) {
+	if err := validateURL(raw); err != nil {
+		return "", err
+	}
+	u, _ := url.Parse(raw)
+	return u.Host, nil
+}
+
+// allowedLink reports whether a link points at the configured GitHub host,
+// allowing everything when GITHUB_BASE_URL is unset
+func (s *Server) allowedLink(raw string) bool {
+	if s.githubHost == "" {
+		return true
+	}
+	u, err := url.Parse(raw)
+	return err == nil && (u.Scheme == "https" || u.Scheme == "http") && strings.EqualFold(u.Host, s.githubHost)
+}
+
+// sanitizeLinks drops links to other hosts from a notification, keeping the
+// text of markdown links so the message still reads naturally
+func (s *Server) sanitizeLinks(message *Notification) (dropped int) {
+	if s.githubHost == "" {
+		return 0
+	}
+
+	strip := func(text string) string {
+		return markdownLink.ReplaceAllStringFunc(text, func(link string) string {
+			match := markdownLink.FindStringSubmatch(link)
+			if s.allowedLink(match[2]) {
+				return link
+			}
+			dropped++
+			return match[1]
+		})
+	}
+
+	if message.URL != "" && !s.allowedLink(message.URL) {
+		message.URL = ""
+		dropped++
+	}
+	if message.Author != nil && message.Author.URL != "" && !s.allowedLink(message.Author.URL) {
+		message.Author.URL = ""
+		dropped++
+	}
+	message.Description = strip(message.Description)
+	for i := range message.Fields {
+		message.Fields[i].Value = strip(message.Fields[i].Value)
+	}
+	return dropped
+}
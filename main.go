@@ -1,302 +1,311 @@
 package main
 
 import (
-	"bytes"
-	"encoding/json"
-	"fmt"
-	"io"
-	"log"
+	"context"
+	"errors"
+	"log/slog"
+	"net"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
+	"github.com/getsentry/sentry-go"
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
 // GitHub webhook payload structures
 type Repository struct {
-	FullName string `json:"full_name"`
-	HTMLURL  string `json:"html_url"`
+	FullName        string `json:"full_name"`
+	HTMLURL         string `json:"html_url"`
+	StargazersCount int    `json:"stargazers_count"`
+	ForksCount      int    `json:"forks_count"`
 }
 
 type Sender struct {
-	Login   string `json:"login"`
-	HTMLURL string `json:"html_url"`
-}
-
-type PullRequest struct {
-	Number  int    `json:"number"`
-	Title   string `json:"title"`
-	HTMLURL string `json:"html_url"`
-	Merged  bool   `json:"merged"`
-	State   string `json:"state"`
-}
-
-type WorkflowRun struct {
-	Name       string `json:"name"`
-	Status     string `json:"status"`
-	Conclusion string `json:"conclusion"`
-	HTMLURL    string `json:"html_url"`
+	Login     string `json:"login"`
+	HTMLURL   string `json:"html_url"`
+	AvatarURL string `json:"avatar_url"`
 }
 
 type GitHubEvent struct {
-	Action      string      `json:"action"`
-	Repository  Repository  `json:"repository"`
-	Sender      Sender      `json:"sender"`
-	PullRequest PullRequest `json:"pull_request"`
-	WorkflowRun WorkflowRun `json:"workflow_run"`
+	Action      string        `json:"action"`
+	Zen         string        `json:"zen"`
+	StarredAt   string        `json:"starred_at"`
+	Repository  Repository    `json:"repository"`
+	Sender      Sender        `json:"sender"`
+	PullRequest PullRequest   `json:"pull_request"`
+	WorkflowRun WorkflowRun   `json:"workflow_run"`
+	Release     Release       `json:"release"`
+	Issue       Issue         `json:"issue"`
+	CheckRun    CheckRun      `json:"check_run"`
+	CheckSuite  CheckSuite    `json:"check_suite"`
+	Discussion  Discussion    `json:"discussion"`
+	Comment     CommitComment `json:"comment"` // Issue or commit comment, CommitID is only set for the latter
+	Review      Review        `json:"review"`
+	Label       Label         `json:"label"`
+
+	DeploymentStatus DeploymentStatus `json:"deployment_status"`
+
+	// Branch or tag created or deleted, alongside the embedded ref
+	RefType string `json:"ref_type"`
+
+	// Repository created by a fork
+	Forkee Forkee `json:"forkee"`
+
+	// GitHub App installation events
+	Installation        Installation             `json:"installation"`
+	Repositories        []InstallationRepository `json:"repositories"`
+	RepositoriesAdded   []InstallationRepository `json:"repositories_added"`
+	RepositoriesRemoved []InstallationRepository `json:"repositories_removed"`
+
+	// Organization events
+	Organization Organization `json:"organization"`
+	Member       Member       `json:"member"`
+	Team         Team         `json:"team"`
+
+	Push
 }
 
-// Discord message structures
-type DiscordEmbed struct {
-	Title       string              `json:"title"`
-	Description string              `json:"description"`
-	Color       int                 `json:"color"`
-	URL         string              `json:"url,omitempty"`
-	Fields      []DiscordEmbedField `json:"fields,omitempty"`
-}
-
-type DiscordEmbedField struct {
-	Name   string `json:"name"`
-	Value  string `json:"value"`
-	Inline bool   `json:"inline,omitempty"`
-}
+// Maximum time to wait for in-flight requests during shutdown
+const shutdownTimeout = 15 * time.Second
 
-type DiscordMessage struct {
-	Content string         `json:"content,omitempty"`
-	Embeds  []DiscordEmbed `json:"embeds,omitempty"`
-}
+// Maximum time to wait for queued notifications to be delivered during
+// shutdown, on top of the HTTP drain
+const drainTimeout = 15 * time.Second
 
-// Channel webhook URLs
-var (
-	developmentChannelWebhook string
-	testingChannelWebhook     string
-)
+// Maximum time to wait for buffered Sentry events during shutdown
+const sentryFlushTimeout = 2 * time.Second
 
 func main() {
 	// Load environment variables
-	if err := godotenv.Load(); err != nil {
-		log.Println("Warning: Error loading .env file")
-	}
-
-	// Get Discord webhook URLs from environment variables
-	developmentChannelWebhook = os.Getenv("DISCORD_DEV_WEBHOOK_URL")
-	testingChannelWebhook = os.Getenv("DISCORD_TEST_WEBHOOK_URL")
+	envErr := godotenv.Load()
 
-	if developmentChannelWebhook == "" || testingChannelWebhook == "" {
-		log.Fatal("Discord webhook URLs not set in environment variables")
+	// Configure structured logging
+	if err := setupLogger(os.Getenv("LOG_LEVEL")); err != nil {
+		fatal("Invalid LOG_LEVEL", "error", err)
+	}
+	if envErr != nil {
+		slog.Warn("Error loading .env file", "error", envErr)
 	}
 
-	// Create Gin router
-	router := gin.Default()
-
-	// Add CORS middleware
-	router.Use(func(c *gin.Context) {
-		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
-		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, GET, OPTIONS")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, X-GitHub-Event, X-Hub-Signature-256")
-		if c.Request.Method == "OPTIONS" {
-			c.AbortWithStatus(204)
-			return
-		}
-		c.Next()
-	})
+	// Read and validate the configuration before starting anything
+	cfg, err := loadConfig()
+	if err != nil {
+		fatal("Invalid configuration", "error", err)
+	}
+	cfg.logSummary()
+
+	destination = cfg.Destination
+	teamsWebhookURL = cfg.TeamsWebhookURL
+	deliveryTimeout = cfg.DeliveryTimeout
+	retryJitter = cfg.RetryJitter
+	messagePrefix, messageSuffix = cfg.MessagePrefix, cfg.MessageSuffix
+	embedsPerMessage = cfg.MaxEmbedsPerMessage
+	signingKey = cfg.SigningKey
+
+	// Log messages instead of sending them when testing formatting locally
+	dryRun = cfg.DryRun
+	if dryRun {
+		slog.Warn("DRY_RUN enabled, messages will be logged instead of sent")
+	}
+	if cfg.DebugEcho {
+		slog.Warn("DEBUG_ECHO enabled, ?echo=1 returns parsed webhook payloads")
+	}
+	if cfg.WebhookSecret == "" {
+		slog.Warn("GITHUB_WEBHOOK_SECRET not set, webhook signatures will not be verified")
+	}
 
-	// GitHub webhook endpoint
-	router.POST("/webhook/github", handleGitHubWebhook)
+	server := newServer(cfg)
 
-	// Health check endpoint
-	router.GET("/health", func(c *gin.Context) {
-		c.JSON(200, gin.H{
-			"status": "ok",
-		})
-	})
+	// Deliver notifications in the background through a fixed worker pool
+	startDeliveryWorkers(cfg.DeliveryWorkers, cfg.DeliveryQueueSize)
 
-	// Start the server
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8088" // Default port
+	// Batch notifications for the same repo and event arriving within the window
+	if cfg.BatchWindow > 0 {
+		batches = newNotificationBatcher(cfg.BatchWindow)
 	}
-	log.Printf("Starting webhook server on port %s", port)
-	log.Fatal(router.Run(":" + port))
-}
 
-func handleGitHubWebhook(c *gin.Context) {
-	// Get the event type from the header
-	eventType := c.GetHeader("X-GitHub-Event")
-	log.Printf("Received GitHub webhook event: %s", eventType)
-
-	// Read the request body
-	body, err := io.ReadAll(c.Request.Body)
-	if err != nil {
-		log.Printf("Error reading request body: %v", err)
-		c.JSON(400, gin.H{"error": "Unable to read request body"})
-		return
+	// Report delivery failures to Sentry when configured
+	if cfg.SentryDSN != "" {
+		if err := sentry.Init(sentry.ClientOptions{Dsn: cfg.SentryDSN}); err != nil {
+			fatal("Error initializing Sentry", "error", err)
+		}
+		sentryEnabled = true
 	}
 
-	// Parse the GitHub event
-	var event GitHubEvent
-	if err := json.Unmarshal(body, &event); err != nil {
-		log.Printf("Error parsing webhook payload: %v", err)
-		c.JSON(400, gin.H{"error": "Invalid JSON payload"})
-		return
+	// Keep a durable record of every processed webhook
+	if cfg.AuditLogPath != "" {
+		audit, err = openAuditLog(cfg.AuditLogPath)
+		if err != nil {
+			fatal("Error opening audit log", "path", cfg.AuditLogPath, "error", err)
+		}
 	}
 
-	// Process different event types
-	switch eventType {
-	case "pull_request":
-		handlePullRequestEvent(event)
-	case "workflow_run":
-		handleWorkflowRunEvent(event)
-	default:
-		log.Printf("Ignoring unhandled event type: %s", eventType)
+	// Keep notifications that exhausted their retries for a later replay
+	if cfg.DeadLetterPath != "" {
+		deadLetters, err = openDeadLetterLog(cfg.DeadLetterPath)
+		if err != nil {
+			fatal("Error opening dead-letter file", "path", cfg.DeadLetterPath, "error", err)
+		}
 	}
 
-	// Respond to GitHub with a success message
-	c.JSON(200, gin.H{"message": "Webhook received successfully"})
-}
+	// Create Gin router with panic recovery and structured request logs
+	router := gin.New()
+	router.Use(gin.Recovery(), requestLogger())
 
-func handlePullRequestEvent(event GitHubEvent) {
-	log.Printf("Processing pull request event: %s", event.Action)
-
-	// We only want to handle specific actions
-	actionsToProcess := map[string]bool{
-		"opened":           true,
-		"reopened":         true,
-		"ready_for_review": true,
-		"closed":           true,
+	// c.ClientIP(), used for rate limiting and the IP allowlist, only honors
+	// X-Forwarded-For from these proxies
+	if err := router.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		fatal("Error setting trusted proxies", "error", err)
 	}
 
-	if !actionsToProcess[event.Action] {
-		log.Printf("Ignoring PR action: %s", event.Action)
-		return
+	// Only accept requests from GitHub's webhook ranges when enabled
+	if cfg.IPAllowlist {
+		cidrs := cfg.IPCIDRs
+		if len(cidrs) == 0 {
+			if cidrs, err = fetchGitHubHookCIDRs(); err != nil {
+				fatal("Error fetching GitHub webhook IP ranges", "error", err)
+			}
+		}
+		slog.Info("Restricting requests to GitHub webhook IP ranges", "ranges", len(cidrs))
+		router.Use(ipAllowlist(cidrs, "/health"))
 	}
 
-	// If the PR is closed but not merged, we don't notify
-	if event.Action == "closed" && !event.PullRequest.Merged {
-		log.Printf("PR was closed without merging, not sending notification")
-		return
+	// Add CORS middleware only for explicitly allowed origins, GitHub itself doesn't need CORS
+	if len(cfg.AllowedOrigins) > 0 {
+		router.Use(corsMiddleware(cfg.AllowedOrigins))
 	}
 
-	// Determine the color based on the action
-	color := 0x1D82F7 // Default blue color
-	if event.Action == "closed" && event.PullRequest.Merged {
-		color = 0x6E48CD // Purple for merged PRs
+	// GitHub webhook endpoint, rate limited per client when configured
+	webhookHandlers := []gin.HandlerFunc{server.handleGitHubWebhook}
+	if cfg.RateLimitRPS > 0 {
+		webhookHandlers = append([]gin.HandlerFunc{rateLimit(newIPRateLimiter(cfg.RateLimitRPS, cfg.RateLimitBurst))}, webhookHandlers...)
 	}
-
-	// Create a descriptive action message
-	actionDesc := event.Action
-	if event.Action == "closed" && event.PullRequest.Merged {
-		actionDesc = "merged"
+	router.POST("/webhook/github", webhookHandlers...)
+
+	// Operational endpoints move to their own router when ADMIN_PORT is set,
+	// leaving only the webhook on the public listener
+	internal := router
+	if cfg.AdminAddr != "" {
+		internal = gin.New()
+		internal.Use(gin.Recovery(), requestLogger())
 	}
 
-	// Create the Discord message
-	message := DiscordMessage{
-		Embeds: []DiscordEmbed{
-			{
-				Title: fmt.Sprintf("Pull Request %s", actionDesc),
-				Description: fmt.Sprintf("**%s** %s [#%d: %s](%s)",
-					event.Sender.Login,
-					actionDesc,
-					event.PullRequest.Number,
-					event.PullRequest.Title,
-					event.PullRequest.HTMLURL),
-				Color: color,
-				URL:   event.PullRequest.HTMLURL,
-				Fields: []DiscordEmbedField{
-					{
-						Name:   "Repository",
-						Value:  fmt.Sprintf("[%s](%s)", event.Repository.FullName, event.Repository.HTMLURL),
-						Inline: true,
-					},
-					{
-						Name:   "PR Status",
-						Value:  event.PullRequest.State,
-						Inline: true,
-					},
-				},
-			},
-		},
+	// Prometheus metrics endpoint
+	internal.GET("/metrics", gin.WrapH(promhttp.Handler()))
+
+	// Health check endpoint
+	internal.GET("/health", handleHealth)
+
+	// Readiness check verifying the webhooks are reachable
+	internal.GET("/ready", server.handleReady)
+
+	// Admin endpoints for operators, only exposed when a token is configured
+	if cfg.AdminToken != "" {
+		admin := internal.Group("/admin", adminAuth(cfg.AdminToken))
+		admin.GET("/status", handleAdminStatus)
+		admin.POST("/webhooks/enable", handleAdminEnableWebhook)
+		admin.POST("/replay", handleAdminReplay)
+		internal.POST("/reload", adminAuth(cfg.AdminToken), server.handleReload)
 	}
 
-	// Send the message to the development channel
-	sendDiscordMessage(developmentChannelWebhook, message)
-}
+	// Start the server
+	httpServer := &http.Server{
+		Addr:    cfg.Addr,
+		Handler: router,
+	}
 
-func handleWorkflowRunEvent(event GitHubEvent) {
-	log.Printf("Processing workflow run event: %s", event.Action)
+	// Stop accepting requests on SIGINT/SIGTERM
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-	// Only process completed workflow runs
-	if event.Action != "completed" {
-		log.Printf("Ignoring workflow run action: %s", event.Action)
-		return
+	// Bind before serving so the startup announcement only goes out once
+	// the listener is up
+	listener, err := net.Listen("tcp", httpServer.Addr)
+	if err != nil {
+		fatal("Server error", "error", err)
 	}
 
-	// Determine color based on the conclusion
-	color := 0xE6E6E6 // Gray for unknown status
-	switch event.WorkflowRun.Conclusion {
-	case "success":
-		color = 0x2ECC71 // Green
-	case "failure":
-		color = 0xE74C3C // Red
-	case "cancelled":
-		color = 0xF39C12 // Yellow-Orange
-	case "skipped":
-		color = 0x95A5A6 // Gray-Blue
+	go func() {
+		slog.Info("Starting webhook server", "version", version, "addr", httpServer.Addr, "tls", cfg.TLSCertFile != "")
+
+		// Terminate TLS directly when a certificate and key are configured
+		var err error
+		if cfg.TLSCertFile != "" {
+			err = httpServer.ServeTLS(listener, cfg.TLSCertFile, cfg.TLSKeyFile)
+		} else {
+			err = httpServer.Serve(listener)
+		}
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			fatal("Server error", "error", err)
+		}
+	}()
+
+	// Confirm in the dev channel that this version came up
+	if cfg.PostStartupMessage {
+		if len(cfg.StartupWebhooks) == 0 {
+			slog.Warn("POST_STARTUP_MESSAGE set but DISCORD_DEV_WEBHOOK_URL is empty, not announcing startup")
+		}
+		announceStartup(cfg.StartupWebhooks)
 	}
 
-	// Create the Discord message
-	message := DiscordMessage{
-		Embeds: []DiscordEmbed{
-			{
-				Title: fmt.Sprintf("Workflow Run %s", event.WorkflowRun.Conclusion),
-				Description: fmt.Sprintf("Workflow **%s** %s",
-					event.WorkflowRun.Name,
-					event.WorkflowRun.Conclusion),
-				Color: color,
-				URL:   event.WorkflowRun.HTMLURL,
-				Fields: []DiscordEmbedField{
-					{
-						Name:   "Repository",
-						Value:  fmt.Sprintf("[%s](%s)", event.Repository.FullName, event.Repository.HTMLURL),
-						Inline: true,
-					},
-					{
-						Name:   "Triggered by",
-						Value:  fmt.Sprintf("[%s](%s)", event.Sender.Login, event.Sender.HTMLURL),
-						Inline: true,
-					},
-				},
-			},
-		},
+	// The private listener never terminates TLS, it shouldn't be reachable publicly
+	var adminServer *http.Server
+	if cfg.AdminAddr != "" {
+		adminServer = &http.Server{
+			Addr:    cfg.AdminAddr,
+			Handler: internal,
+		}
+		go func() {
+			slog.Info("Starting admin server", "addr", adminServer.Addr)
+			if err := adminServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				fatal("Admin server error", "error", err)
+			}
+		}()
 	}
 
-	// Send the message to the testing channel
-	sendDiscordMessage(testingChannelWebhook, message)
-}
+	<-ctx.Done()
+	slog.Info("Shutting down webhook server")
 
-func sendDiscordMessage(webhookURL string, message DiscordMessage) {
-	// Convert message to JSON
-	jsonData, err := json.Marshal(message)
-	if err != nil {
-		log.Printf("Error marshaling Discord message: %v", err)
-		return
+	// Give in-flight deliveries time to finish
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := httpServer.Shutdown(shutdownCtx); err != nil {
+		slog.Error("Error during server shutdown", "error", err)
 	}
 
-	// Send HTTP POST to Discord webhook
-	resp, err := http.Post(webhookURL, "application/json", bytes.NewBuffer(jsonData))
-	if err != nil {
-		log.Printf("Error sending Discord message: %v", err)
-		return
+	// Keep health and metrics up until the public listener has drained
+	if adminServer != nil {
+		if err := adminServer.Shutdown(shutdownCtx); err != nil {
+			slog.Error("Error during admin server shutdown", "error", err)
+		}
 	}
-	defer resp.Body.Close()
 
-	// Check response status
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		log.Printf("Discord API error (status %d): %s", resp.StatusCode, string(bodyBytes))
-		return
+	// Flush notifications that are still batched or queued
+	if batches != nil {
+		batches.flushAll()
 	}
-
-	log.Printf("Discord message sent successfully")
+	drainCtx, cancelDrain := context.WithTimeout(context.Background(), drainTimeout)
+	defer cancelDrain()
+	if err := stopDeliveryWorkers(drainCtx); err != nil {
+		slog.Error("Timed out flushing queued notifications", "error", err)
+	}
+	if sentryEnabled {
+		sentry.Flush(sentryFlushTimeout)
+	}
+	if audit != nil {
+		if err := audit.Close(); err != nil {
+			slog.Error("Error closing audit log", "error", err)
+		}
+	}
+	if deadLetters != nil {
+		if err := deadLetters.Close(); err != nil {
+			slog.Error("Error closing dead-letter file", "error", err)
+		}
+	}
+	slog.Info("Webhook server stopped")
 }
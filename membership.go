@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+)
+
+// GitHub membership payload structures
+type Member struct {
+	Login   string `json:"login"`
+	HTMLURL string `json:"html_url"`
+}
+
+type Team struct {
+	Name    string `json:"name"`
+	HTMLURL string `json:"html_url"`
+}
+
+type Organization struct {
+	Login string `json:"login"`
+}
+
+func (s *Server) handleMembershipEvent(event GitHubEvent) *Notification {
+	slog.Info("Processing membership event", "action", event.Action)
+
+	// Only team additions and removals are sent
+	var verb string
+	switch event.Action {
+	case "added":
+		verb = "added to"
+	case "removed":
+		verb = "removed from"
+	default:
+		slog.Info("Ignoring membership action", "action", event.Action)
+		return nil
+	}
+
+	// Create the notification
+	message := Notification{
+		Title: fmt.Sprintf("Team member %s", event.Action),
+		Description: fmt.Sprintf("**%s** %s [%s](%s) by **%s**",
+			event.Member.Login,
+			verb,
+			escapeMarkdown(event.Team.Name),
+			event.Team.HTMLURL,
+			event.Sender.Login),
+		Color: colorFor("membership", event.Action),
+		URL:   event.Team.HTMLURL,
+		Fields: []NotificationField{
+			{
+				Name:   "Member",
+				Value:  fmt.Sprintf("[%s](%s)", event.Member.Login, event.Member.HTMLURL),
+				Inline: true,
+			},
+		},
+	}
+
+	if event.Organization.Login != "" {
+		message.Fields = append(message.Fields, NotificationField{
+			Name:   "Organization",
+			Value:  event.Organization.Login,
+			Inline: true,
+		})
+	}
+
+	return &message
+}
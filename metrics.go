@@ -0,0 +1,25 @@
+package main
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Prometheus metrics exposed on /metrics
+var (
+	webhooksReceived = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "webhooks_received_total",
+		Help: "GitHub webhooks received, by event type.",
+	}, []string{"event_type"})
+
+	discordDeliveries = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "discord_deliveries_total",
+		Help: "Discord webhook deliveries, by result.",
+	}, []string{"result"})
+
+	discordRequestDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name:    "discord_request_duration_seconds",
+		Help:    "Latency of Discord webhook requests.",
+		Buckets: prometheus.DefBuckets,
+	})
+)
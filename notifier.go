@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+)
+
+// Footer shown on every notification
+const notificationFooter = "via github-discord bridge"
+
+// Destination-neutral notification built by the event handlers
+type Notification struct {
+	Content     string
+	Title       string
+	Description string
+	URL         string
+	Color       int
+	Fields      []NotificationField
+	Timestamp   time.Time
+	Footer      string
+
+	// Author is the GitHub user shown at the top of the notification
+	Author *NotificationAuthor
+
+	// Mention is a role ID or "@here"/"@everyone" to ping with the notification
+	Mention string
+
+	// Username and AvatarURL override the sender identity when set
+	Username  string
+	AvatarURL string
+
+	// ThreadName names the post created on forum channel destinations, and is
+	// cleared for every other destination
+	ThreadName string
+
+	// MessageKey identifies a message that later notifications may update
+	// in place, on destinations that support editing
+	MessageKey     string
+	UpdateExisting bool
+}
+
+type NotificationAuthor struct {
+	Name    string
+	URL     string
+	IconURL string
+}
+
+type NotificationField struct {
+	Name   string
+	Value  string
+	Inline bool
+}
+
+// Notifier delivers a notification to a single destination
+type Notifier interface {
+	Send(ctx context.Context, message Notification) error
+}
+
+// BatchNotifier is implemented by notifiers that can combine several
+// notifications into a single message
+type BatchNotifier interface {
+	SendBatch(ctx context.Context, messages []Notification) error
+}
+
+// Supported notification destinations
+const (
+	destinationDiscord = "discord"
+	destinationSlack   = "slack"
+	destinationTeams   = "teams"
+)
+
+// Destination selected via the DESTINATION env var
+var destination = destinationDiscord
+
+// When set, payloads are logged instead of sent
+var dryRun bool
+
+// requestTimeoutKey carries a delivery's per-request timeout in its context
+type requestTimeoutKey struct{}
+
+// withRequestTimeout sets the timeout applied to each HTTP request sent with ctx
+func withRequestTimeout(ctx context.Context, timeout time.Duration) context.Context {
+	return context.WithValue(ctx, requestTimeoutKey{}, timeout)
+}
+
+// requestContext bounds a single HTTP round trip by the delivery's request
+// timeout, leaving rate limit waits and later messages their own time
+func requestContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if timeout, ok := ctx.Value(requestTimeoutKey{}).(time.Duration); ok && timeout > 0 {
+		return context.WithTimeout(ctx, timeout)
+	}
+	return context.WithCancel(ctx)
+}
+
+// logDryRun pretty-prints a payload that would have been sent to a destination
+func logDryRun(target string, payload any) error {
+	jsonData, err := json.MarshalIndent(payload, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshaling dry-run payload: %w", err)
+	}
+	slog.Info("Dry run, not sending message", "destination", target)
+	fmt.Fprintln(os.Stderr, string(jsonData))
+	return nil
+}
+
+// newNotifier returns the notifier for a webhook URL on the given destination
+func newNotifier(target, webhookURL string) Notifier {
+	switch target {
+	case destinationSlack:
+		return SlackNotifier{WebhookURL: webhookURL}
+	case destinationTeams:
+		return TeamsNotifier{WebhookURL: webhookURL}
+	}
+	return DiscordNotifier{WebhookURL: webhookURL}
+}
+
+// Sender identity used for a family of notifications
+type botIdentity struct {
+	Username  string
+	AvatarURL string
+}
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+)
+
+// GitHub pull request payload structures
+type PullRequest struct {
+	Number  int     `json:"number"`
+	Title   string  `json:"title"`
+	HTMLURL string  `json:"html_url"`
+	Merged  bool    `json:"merged"`
+	State   string  `json:"state"`
+	Base    GitRef  `json:"base"`
+	Head    GitRef  `json:"head"`
+	User    Sender  `json:"user"`
+	Commits int     `json:"commits"`
+	Labels  []Label `json:"labels"`
+
+	// Diff stats, only sent on some actions
+	Additions    int `json:"additions"`
+	Deletions    int `json:"deletions"`
+	ChangedFiles int `json:"changed_files"`
+}
+
+type GitRef struct {
+	Ref string `json:"ref"`
+	SHA string `json:"sha"`
+}
+
+func (s *Server) handlePullRequestEvent(event GitHubEvent) *Notification {
+	slog.Info("Processing pull request event", "action", event.Action)
+
+	// We only want to handle specific actions
+	actionsToProcess := map[string]bool{
+		"opened":           true,
+		"reopened":         true,
+		"ready_for_review": true,
+		"closed":           true,
+		"synchronize":      s.notifyPRSync,
+		"labeled":          s.triggerLabel != "",
+	}
+
+	if !actionsToProcess[event.Action] {
+		slog.Info("Ignoring PR action", "action", event.Action)
+		return nil
+	}
+
+	// Skip PRs that don't target a filtered branch
+	if len(s.prBranchFilter) > 0 && !matchesAny(s.prBranchFilter, event.PullRequest.Base.Ref) {
+		slog.Info("PR base branch doesn't match the branch filter, not sending notification", "base", event.PullRequest.Base.Ref)
+		return nil
+	}
+
+	// New commits get a compact line rather than a full announcement
+	if event.Action == "synchronize" {
+		return s.prSyncNotification(event)
+	}
+
+	// Only the trigger label is announced, other label changes are noise
+	if event.Action == "labeled" {
+		if event.Label.Name != s.triggerLabel {
+			slog.Info("Ignoring PR label", "label", event.Label.Name)
+			return nil
+		}
+		return s.prLabeledNotification(event)
+	}
+
+	// If the PR is closed but not merged, we don't notify, and there is
+	// no merge left to update the opened message
+	if event.Action == "closed" && !event.PullRequest.Merged {
+		slog.Info("PR was closed without merging, not sending notification")
+		trackedMessages.forget(prMessageKey(event))
+		return nil
+	}
+
+	// Create a descriptive action message
+	actionDesc := event.Action
+	if event.Action == "closed" && event.PullRequest.Merged {
+		actionDesc = "merged"
+	}
+
+	// Create the notification
+	message := Notification{
+		Title: fmt.Sprintf("Pull Request %s", actionDesc),
+		Description: fmt.Sprintf("**%s** %s [#%d: %s](%s)",
+			event.Sender.Login,
+			actionDesc,
+			event.PullRequest.Number,
+			escapeMarkdown(event.PullRequest.Title),
+			event.PullRequest.HTMLURL),
+		Color: colorFor("pr", actionDesc),
+		URL:   event.PullRequest.HTMLURL,
+		Fields: []NotificationField{
+			{
+				Name:   "Repository",
+				Value:  fmt.Sprintf("[%s](%s)", event.Repository.FullName, event.Repository.HTMLURL),
+				Inline: true,
+			},
+			{
+				Name:   "PR Status",
+				Value:  event.PullRequest.State,
+				Inline: true,
+			},
+		},
+		Username:  s.prIdentity.Username,
+		AvatarURL: s.prIdentity.AvatarURL,
+	}
+
+	// Make it clear a reopened PR was closed before, not brand new
+	if event.Action == "reopened" {
+		message.Description += "\nThis pull request was previously closed without merging."
+	}
+
+	// Give reviewers a sense of the PR size
+	if pr := event.PullRequest; pr.Additions != 0 || pr.Deletions != 0 || pr.ChangedFiles != 0 {
+		files := "files"
+		if pr.ChangedFiles == 1 {
+			files = "file"
+		}
+		message.Fields = append(message.Fields, NotificationField{
+			Name:   "Changes",
+			Value:  fmt.Sprintf("+%d −%d across %d %s", pr.Additions, pr.Deletions, pr.ChangedFiles, files),
+			Inline: true,
+		})
+	}
+
+	// Credit the author, and whoever acted on the PR when that's someone else
+	author := event.PullRequest.User
+	if author.Login != "" {
+		message.Fields = append(message.Fields, NotificationField{
+			Name:   "Author",
+			Value:  fmt.Sprintf("[%s](%s)", author.Login, author.HTMLURL),
+			Inline: true,
+		})
+	}
+	if author.Login != "" && !strings.EqualFold(author.Login, event.Sender.Login) {
+		message.Fields = append(message.Fields, NotificationField{
+			Name:   "Action by",
+			Value:  fmt.Sprintf("[%s](%s)", event.Sender.Login, event.Sender.HTMLURL),
+			Inline: true,
+		})
+	}
+
+	// Forum channels get a post per PR
+	message.ThreadName = fmt.Sprintf("#%d: %s", event.PullRequest.Number, event.PullRequest.Title)
+
+	// Track opened PRs so the merge can update the original message
+	if event.Action == "opened" || event.Action == "closed" {
+		message.MessageKey = prMessageKey(event)
+		message.UpdateExisting = event.Action == "closed"
+	}
+
+	return &message
+}
+
+// prMessageKey identifies the tracked message for a PR
+func prMessageKey(event GitHubEvent) string {
+	return fmt.Sprintf("%s#%d", event.Repository.FullName, event.PullRequest.Number)
+}
+
+// prSyncNotification builds the compact notification for commits pushed to an open PR
+func (s *Server) prSyncNotification(event GitHubEvent) *Notification {
+	commits := "commits"
+	if event.PullRequest.Commits == 1 {
+		commits = "commit"
+	}
+	return &Notification{
+		Title: fmt.Sprintf("New commits on #%d", event.PullRequest.Number),
+		Description: fmt.Sprintf("**%s** pushed to [#%d: %s](%s), now %d %s at `%s`",
+			event.Sender.Login,
+			event.PullRequest.Number,
+			escapeMarkdown(event.PullRequest.Title),
+			event.PullRequest.HTMLURL,
+			event.PullRequest.Commits,
+			commits,
+			shortSHA(event.PullRequest.Head.SHA)),
+		Color:     colorFor("pr", event.Action),
+		URL:       event.PullRequest.HTMLURL + "/commits",
+		Username:  s.prIdentity.Username,
+		AvatarURL: s.prIdentity.AvatarURL,
+	}
+}
+
+// prLabeledNotification builds the notification for the trigger label being added to a PR
+func (s *Server) prLabeledNotification(event GitHubEvent) *Notification {
+	return &Notification{
+		Title: fmt.Sprintf("%s added to #%d", event.Label.Name, event.PullRequest.Number),
+		Description: fmt.Sprintf("**%s** labeled [#%d: %s](%s) `%s`",
+			event.Sender.Login,
+			event.PullRequest.Number,
+			escapeMarkdown(event.PullRequest.Title),
+			event.PullRequest.HTMLURL,
+			event.Label.Name),
+		Color: colorFor("pr", event.Action),
+		URL:   event.PullRequest.HTMLURL,
+		Fields: []NotificationField{
+			{
+				Name:   "Repository",
+				Value:  fmt.Sprintf("[%s](%s)", event.Repository.FullName, event.Repository.HTMLURL),
+				Inline: true,
+			},
+		},
+		Username:  s.prIdentity.Username,
+		AvatarURL: s.prIdentity.AvatarURL,
+	}
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+// pullRequestPayload builds a pull_request delivery for PR #7 of octo/repo
+func pullRequestPayload(t *testing.T, action string, merged bool) []byte {
+	t.Helper()
+	payload, err := json.Marshal(map[string]any{
+		"action": action,
+		"pull_request": map[string]any{
+			"number":   7,
+			"title":    "Add widgets",
+			"html_url": "https://github.com/octo/repo/pull/7",
+			"merged":   merged,
+			"state":    "open",
+		},
+		"repository": map[string]any{
+			"full_name": "octo/repo",
+			"html_url":  "https://github.com/octo/repo",
+		},
+		"sender": map[string]any{"login": "octocat"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return payload
+}
+
+func TestHandlePullRequestEvent(t *testing.T) {
+	tests := []struct {
+		name       string
+		action     string
+		merged     bool
+		wantSent   bool
+		wantTitle  string
+		wantColor  int
+		wantKey    string
+		wantUpdate bool
+	}{
+		{
+			name:      "opened",
+			action:    "opened",
+			wantSent:  true,
+			wantTitle: "Pull Request opened",
+			wantColor: defaultColors["pr_default"],
+			wantKey:   "octo/repo#7",
+		},
+		{
+			name:       "merged",
+			action:     "closed",
+			merged:     true,
+			wantSent:   true,
+			wantTitle:  "Pull Request merged",
+			wantColor:  defaultColors["pr_merged"],
+			wantKey:    "octo/repo#7",
+			wantUpdate: true,
+		},
+		{
+			name:   "closed without merging",
+			action: "closed",
+		},
+		{
+			name:      "reopened",
+			action:    "reopened",
+			wantSent:  true,
+			wantTitle: "Pull Request reopened",
+			wantColor: defaultColors["pr_reopened"],
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, jobs := newTestServer(t)
+			rec := serveWebhook(s, newWebhookRequest("pull_request", pullRequestPayload(t, tt.action, tt.merged)))
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d, want 200: %s", rec.Code, rec.Body.String())
+			}
+
+			if !tt.wantSent {
+				if len(*jobs) != 0 {
+					t.Fatalf("delivered %d jobs, want none", len(*jobs))
+				}
+				return
+			}
+			if len(*jobs) != 1 {
+				t.Fatalf("delivered %d jobs, want 1", len(*jobs))
+			}
+			job := (*jobs)[0]
+			if job.webhookURL != testDevelopmentWebhook {
+				t.Errorf("webhook = %s, want the development webhook", job.webhookURL)
+			}
+			if len(job.messages) != 1 {
+				t.Fatalf("job has %d messages, want 1", len(job.messages))
+			}
+			message := job.messages[0]
+			if message.Title != tt.wantTitle {
+				t.Errorf("title = %q, want %q", message.Title, tt.wantTitle)
+			}
+			if message.Color != tt.wantColor {
+				t.Errorf("color = %#x, want %#x", message.Color, tt.wantColor)
+			}
+			if message.MessageKey != tt.wantKey {
+				t.Errorf("message key = %q, want %q", message.MessageKey, tt.wantKey)
+			}
+			if message.UpdateExisting != tt.wantUpdate {
+				t.Errorf("update existing = %v, want %v", message.UpdateExisting, tt.wantUpdate)
+			}
+		})
+	}
+}
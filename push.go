@@ -0,0 +1,172 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+)
+
+// Maximum number of commits listed in a push notification
+const maxPushCommits = 10
+
+// GitHub push payload structures
+type Push struct {
+	Ref     string   `json:"ref"`
+	Commits []Commit `json:"commits"`
+	Compare string   `json:"compare"`
+	Pusher  Pusher   `json:"pusher"`
+	Forced  bool     `json:"forced"`
+	Deleted bool     `json:"deleted"`
+}
+
+type Commit struct {
+	ID      string       `json:"id"`
+	Message string       `json:"message"`
+	URL     string       `json:"url"`
+	Author  CommitAuthor `json:"author"`
+}
+
+type CommitAuthor struct {
+	Name     string `json:"name"`
+	Username string `json:"username"`
+}
+
+type Pusher struct {
+	Name  string `json:"name"`
+	Email string `json:"email"`
+}
+
+func (s *Server) handlePushEvent(event GitHubEvent) *Notification {
+	slog.Info("Processing push event", "ref", event.Ref)
+
+	// Deleted refs are announced by the delete event
+	if event.Deleted {
+		slog.Info("Push deleted the ref, not sending notification")
+		return nil
+	}
+
+	// Tag pushes are announced differently than branch pushes
+	if strings.HasPrefix(event.Ref, "refs/tags/") {
+		tag := strings.TrimPrefix(event.Ref, "refs/tags/")
+		message := Notification{
+			Title:       "Tag pushed",
+			Description: fmt.Sprintf("**%s** pushed tag `%s`", event.Pusher.Name, tag),
+			Color:       colorFor("push", "tag"),
+			URL:         event.Compare,
+			Fields: []NotificationField{
+				{
+					Name:   "Repository",
+					Value:  fmt.Sprintf("[%s](%s)", event.Repository.FullName, event.Repository.HTMLURL),
+					Inline: true,
+				},
+			},
+		}
+		return &message
+	}
+
+	branch := strings.TrimPrefix(event.Ref, "refs/heads/")
+
+	// New branches without commits are announced by the create event, only
+	// a force push resetting the branch is worth a notification
+	if len(event.Commits) == 0 && !event.Forced {
+		slog.Info("Push has no commits, not sending notification")
+		return nil
+	}
+
+	// Large pushes are summarized per author rather than listed
+	var lines []string
+	if s.pushSummaryThreshold > 0 && len(event.Commits) > s.pushSummaryThreshold {
+		lines = summarizeCommitsByAuthor(event.Commits)
+	} else {
+		// List up to maxPushCommits commits with their short SHA and author
+		for i, commit := range event.Commits {
+			if i == maxPushCommits {
+				lines = append(lines, fmt.Sprintf("…and %d more", len(event.Commits)-maxPushCommits))
+				break
+			}
+			lines = append(lines, fmt.Sprintf("[`%s`](%s) %s - %s",
+				shortSHA(commit.ID),
+				commit.URL,
+				escapeMarkdown(firstLine(commit.Message)),
+				escapeMarkdown(commit.Author.Name)))
+		}
+	}
+
+	description := fmt.Sprintf("**%s** pushed %d commit(s) to `%s`", event.Pusher.Name, len(event.Commits), branch)
+	if len(lines) > 0 {
+		description += "\n\n" + strings.Join(lines, "\n")
+	}
+
+	// Create the notification
+	message := Notification{
+		Title:       fmt.Sprintf("Push to %s", branch),
+		Description: description,
+		Color:       colorFor("push", "branch"),
+		URL:         event.Compare,
+		Fields: []NotificationField{
+			{
+				Name:   "Repository",
+				Value:  fmt.Sprintf("[%s](%s)", event.Repository.FullName, event.Repository.HTMLURL),
+				Inline: true,
+			},
+			{
+				Name:   "Changes",
+				Value:  fmt.Sprintf("[View changes](%s)", event.Compare),
+				Inline: true,
+			},
+		},
+	}
+
+	// Rewritten history is flagged so it can't go unnoticed
+	if event.Forced {
+		message.Title = fmt.Sprintf("Force push to %s", branch)
+		message.Description = "⚠️ " + strings.Replace(message.Description, " pushed ", " force-pushed ", 1)
+		message.Color = colorFor("push", "forced")
+		message.Mention = s.mentionOnForcePush
+	}
+
+	return &message
+}
+
+// summarizeCommitsByAuthor counts commits per author in order of first
+// appearance, e.g. "alice: 4 commits, bob: 2 commits"
+func summarizeCommitsByAuthor(commits []Commit) []string {
+	var authors []string
+	counts := make(map[string]int)
+	for _, commit := range commits {
+		author := commit.Author.Username
+		if author == "" {
+			author = commit.Author.Name
+		}
+		if counts[author] == 0 {
+			authors = append(authors, author)
+		}
+		counts[author]++
+	}
+
+	parts := make([]string, len(authors))
+	for i, author := range authors {
+		noun := "commits"
+		if counts[author] == 1 {
+			noun = "commit"
+		}
+		parts[i] = fmt.Sprintf("%s: %d %s", escapeMarkdown(author), counts[author], noun)
+	}
+	return []string{strings.Join(parts, ", "), fmt.Sprintf("Total: %d commits", len(commits))}
+}
+
+// shortSHA returns the abbreviated 7 character form of a commit SHA
+func shortSHA(sha string) string {
+	if len(sha) > 7 {
+		return sha[:7]
+	}
+	return sha
+}
+
+// firstLine returns the summary line of a commit message
+func firstLine(s string) string {
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		return s[:i]
+	}
+	return s
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"log/slog"
+	"testing"
+)
+
+func TestHandlePushEventSkipsEmptyPushes(t *testing.T) {
+	commit := Commit{ID: "0123456789abcdef", Message: "Add widgets", Author: CommitAuthor{Name: "Mona"}}
+	tests := []struct {
+		name     string
+		push     Push
+		wantSent bool
+	}{
+		{name: "commits", push: Push{Ref: "refs/heads/main", Commits: []Commit{commit}}, wantSent: true},
+		{name: "new branch without commits", push: Push{Ref: "refs/heads/feature"}},
+		{name: "deleted branch", push: Push{Ref: "refs/heads/feature", Deleted: true}},
+		{name: "deleted tag", push: Push{Ref: "refs/tags/v1.0.0", Deleted: true}},
+		{name: "tag", push: Push{Ref: "refs/tags/v1.0.0"}, wantSent: true},
+		{name: "force push resetting the branch", push: Push{Ref: "refs/heads/main", Forced: true}, wantSent: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, _ := newTestServer(t)
+			message := s.handleEvent("push", GitHubEvent{Push: tt.push}, slog.Default())
+			if sent := message != nil; sent != tt.wantSent {
+				t.Errorf("sent = %v, want %v", sent, tt.wantSent)
+			}
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Quiet hours configuration, disabled when quietHoursLocation is nil
+var (
+	quietHoursStart    int
+	quietHoursEnd      int
+	quietHoursLocation *time.Location
+	quietHoursBypass   []string
+)
+
+// loadQuietHours reads the QUIET_HOURS_* env vars, leaving quiet hours
+// disabled unless both a start and end hour are set
+func loadQuietHours() error {
+	start, end := os.Getenv("QUIET_HOURS_START"), os.Getenv("QUIET_HOURS_END")
+	if start == "" && end == "" {
+		return nil
+	}
+
+	var err error
+	if quietHoursStart, err = parseHour(start); err != nil {
+		return fmt.Errorf("QUIET_HOURS_START: %w", err)
+	}
+	if quietHoursEnd, err = parseHour(end); err != nil {
+		return fmt.Errorf("QUIET_HOURS_END: %w", err)
+	}
+
+	location := time.UTC
+	if tz := os.Getenv("QUIET_HOURS_TZ"); tz != "" {
+		if location, err = time.LoadLocation(tz); err != nil {
+			return fmt.Errorf("QUIET_HOURS_TZ: %w", err)
+		}
+	}
+	quietHoursLocation = location
+
+	// Workflow failures, timeouts included, are urgent and bypass quiet hours by default
+	quietHoursBypass = []string{"workflow_run:failure", "workflow_run:timed_out"}
+	if value, ok := os.LookupEnv("QUIET_HOURS_BYPASS_EVENTS"); ok {
+		quietHoursBypass = splitList(value)
+	}
+	return nil
+}
+
+func parseHour(value string) (int, error) {
+	hour, err := strconv.Atoi(value)
+	if err != nil || hour < 0 || hour > 23 {
+		return 0, fmt.Errorf("invalid hour %q, expected 0-23", value)
+	}
+	return hour, nil
+}
+
+// inQuietHours reports whether now falls in the configured quiet window,
+// which may wrap around midnight (e.g. 18 to 9)
+func inQuietHours(now time.Time) bool {
+	if quietHoursLocation == nil || quietHoursStart == quietHoursEnd {
+		return false
+	}
+
+	hour := now.In(quietHoursLocation).Hour()
+	if quietHoursStart < quietHoursEnd {
+		return hour >= quietHoursStart && hour < quietHoursEnd
+	}
+	return hour >= quietHoursStart || hour < quietHoursEnd
+}
+
+// bypassesQuietHours reports whether an event matches QUIET_HOURS_BYPASS_EVENTS,
+// whose entries are either an event type or "<event>:<outcome>"
+func bypassesQuietHours(eventType string, event GitHubEvent) bool {
+	outcome := eventOutcome(eventType, event)
+	for _, entry := range quietHoursBypass {
+		bypassEvent, bypassOutcome, hasOutcome := strings.Cut(entry, ":")
+		if bypassEvent == eventType && (!hasOutcome || bypassOutcome == outcome) {
+			return true
+		}
+	}
+	return false
+}
+
+// eventOutcome returns the conclusion for CI events, "merged" for merged PRs
+// and the action otherwise
+func eventOutcome(eventType string, event GitHubEvent) string {
+	switch eventType {
+	case "workflow_run":
+		return event.WorkflowRun.Conclusion
+	case "check_run":
+		return event.CheckRun.Conclusion
+	case "check_suite":
+		return event.CheckSuite.Conclusion
+	case "deployment_status":
+		return event.DeploymentStatus.State
+	case "pull_request":
+		if event.Action == "closed" && event.PullRequest.Merged {
+			return "merged"
+		}
+	}
+	return event.Action
+}
@@ -0,0 +1,24 @@
+package main
+
+import "testing"
+
+func TestQuietHoursBypassesFailedWorkflowsByDefault(t *testing.T) {
+	t.Setenv("QUIET_HOURS_START", "22")
+	t.Setenv("QUIET_HOURS_END", "7")
+	defer func() { quietHoursLocation, quietHoursBypass = nil, nil }()
+	if err := loadQuietHours(); err != nil {
+		t.Fatal(err)
+	}
+
+	for conclusion, want := range map[string]bool{
+		"failure":   true,
+		"timed_out": true,
+		"success":   false,
+		"cancelled": false,
+	} {
+		event := GitHubEvent{WorkflowRun: WorkflowRun{Conclusion: conclusion}}
+		if got := bypassesQuietHours("workflow_run", event); got != want {
+			t.Errorf("bypassesQuietHours(%s) = %v, want %v", conclusion, got, want)
+		}
+	}
+}
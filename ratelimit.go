@@ -0,0 +1,69 @@
+package main
+
+import (
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/time/rate"
+)
+
+// How long an idle client's limiter is kept before being forgotten
+const rateLimiterIdleTTL = 10 * time.Minute
+
+// ipRateLimiter keeps a token bucket per client IP
+type ipRateLimiter struct {
+	mu        sync.Mutex
+	rps       rate.Limit
+	burst     int
+	limiters  map[string]*clientLimiter
+	lastSweep time.Time
+}
+
+type clientLimiter struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+func newIPRateLimiter(rps float64, burst int) *ipRateLimiter {
+	return &ipRateLimiter{
+		rps:      rate.Limit(rps),
+		burst:    burst,
+		limiters: make(map[string]*clientLimiter),
+	}
+}
+
+// allow reports whether a request from ip fits within its rate limit
+func (l *ipRateLimiter) allow(ip string, now time.Time) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// Forget clients that have gone quiet so the map doesn't grow forever
+	if now.Sub(l.lastSweep) > rateLimiterIdleTTL {
+		for key, client := range l.limiters {
+			if now.Sub(client.lastSeen) > rateLimiterIdleTTL {
+				delete(l.limiters, key)
+			}
+		}
+		l.lastSweep = now
+	}
+
+	client, ok := l.limiters[ip]
+	if !ok {
+		client = &clientLimiter{limiter: rate.NewLimiter(l.rps, l.burst)}
+		l.limiters[ip] = client
+	}
+	client.lastSeen = now
+	return client.limiter.AllowN(now, 1)
+}
+
+// rateLimit rejects requests from clients exceeding their rate limit
+func rateLimit(limiter *ipRateLimiter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !limiter.allow(c.ClientIP(), time.Now()) {
+			abortWithError(c, 429, errCodeRateLimited, "Too Many Requests")
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// How long a readiness result is reused before Discord is probed again
+const readinessCacheTTL = 30 * time.Second
+
+// Overall deadline for probing every webhook, within typical probe timeouts
+const readinessTimeout = 5 * time.Second
+
+// readinessCache remembers the outcome of the last webhook reachability probe
+type readinessCache struct {
+	mu        sync.Mutex
+	checkedAt time.Time
+	err       error
+}
+
+var readiness readinessCache
+
+// check probes every webhook concurrently, reusing a recent result when
+// available. The lock only guards the cached result, never the probes.
+func (rc *readinessCache) check(now time.Time, webhooks []string) error {
+	rc.mu.Lock()
+	if !rc.checkedAt.IsZero() && now.Sub(rc.checkedAt) < readinessCacheTTL {
+		defer rc.mu.Unlock()
+		return rc.err
+	}
+	rc.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), readinessTimeout)
+	defer cancel()
+	errs := make([]error, len(webhooks))
+	var wg sync.WaitGroup
+	for i, webhookURL := range webhooks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = probeDiscordWebhook(ctx, webhookURL)
+		}()
+	}
+	wg.Wait()
+
+	// Report the first failing webhook in config order
+	var err error
+	for i, probeErr := range errs {
+		if probeErr != nil {
+			err = fmt.Errorf("webhook %d: %w", i, probeErr)
+			break
+		}
+	}
+
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.err = err
+	rc.checkedAt = now
+	return err
+}
+
+// probeDiscordWebhook fetches the webhook metadata Discord returns on GET
+func probeDiscordWebhook(ctx context.Context, webhookURL string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, webhookURL, nil)
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+	resp, err := discordClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("unreachable: %w", redactError(err))
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func (s *Server) handleReady(c *gin.Context) {
+	// Only Discord answers GET requests on its webhook URLs
+	if destination != destinationDiscord {
+		c.JSON(200, gin.H{"status": "ready"})
+		return
+	}
+
+	if err := readiness.check(time.Now(), s.currentRoutes().allWebhooks()); err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"status": "unavailable",
+			"error":  err.Error(),
+		})
+		return
+	}
+	c.JSON(200, gin.H{"status": "ready"})
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReadinessProbesWebhooksConcurrently(t *testing.T) {
+	const delay = 200 * time.Millisecond
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(delay)
+	}))
+	defer slow.Close()
+	broken := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(delay)
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer broken.Close()
+
+	webhooks := []string{slow.URL + "/1", slow.URL + "/2", broken.URL + "/3", slow.URL + "/4"}
+	var rc readinessCache
+	start := time.Now()
+	err := rc.check(start, webhooks)
+	if elapsed := time.Since(start); elapsed > 2*delay {
+		t.Errorf("check took %s for %d webhooks, want them probed concurrently", elapsed, len(webhooks))
+	}
+	if err == nil || !strings.HasPrefix(err.Error(), "webhook 2:") {
+		t.Fatalf("check error = %v, want webhook 2 reported", err)
+	}
+
+	// A fresh result is reused without probing again
+	broken.Close()
+	if cached := rc.check(start.Add(time.Second), webhooks); cached != err {
+		t.Errorf("cached error = %v, want %v", cached, err)
+	}
+}
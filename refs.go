@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+)
+
+// handleRefEvent handles create and delete events for branches and tags
+func (s *Server) handleRefEvent(eventType string, event GitHubEvent) *Notification {
+	slog.Info("Processing ref event", "event", eventType, "ref_type", event.RefType, "ref", event.Ref)
+
+	// Repositories are announced by other events
+	if event.RefType != "branch" && event.RefType != "tag" {
+		slog.Info("Ignoring ref type", "ref_type", event.RefType)
+		return nil
+	}
+
+	// Only notify for refs matching the filter, such as version tags
+	if s.refFilter != nil && !s.refFilter.MatchString(event.Ref) {
+		slog.Info("Ref doesn't match the ref filter, not sending notification", "ref", event.Ref)
+		return nil
+	}
+
+	verb := "created"
+	url := fmt.Sprintf("%s/tree/%s", event.Repository.HTMLURL, event.Ref)
+	if eventType == "delete" {
+		verb = "deleted"
+		url = event.Repository.HTMLURL
+	}
+	refType := strings.ToUpper(event.RefType[:1]) + event.RefType[1:]
+
+	// Create the notification
+	return &Notification{
+		Title: fmt.Sprintf("%s %s %s", refType, event.Ref, verb),
+		Description: fmt.Sprintf("%s `%s` %s by **%s**",
+			refType,
+			event.Ref,
+			verb,
+			event.Sender.Login),
+		Color: colorFor("ref", eventType),
+		URL:   url,
+		Fields: []NotificationField{
+			{
+				Name:   "Repository",
+				Value:  fmt.Sprintf("[%s](%s)", event.Repository.FullName, event.Repository.HTMLURL),
+				Inline: true,
+			},
+		},
+	}
+}
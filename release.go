@@ -0,0 +1,110 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+)
+
+// Maximum length of the release notes shown in the embed description
+const maxReleaseBodyLength = 1024
+
+// Maximum number of assets listed before summarizing the rest
+const maxReleaseAssets = 10
+
+// GitHub release payload structure
+type Release struct {
+	TagName    string `json:"tag_name"`
+	Name       string `json:"name"`
+	Body       string `json:"body"`
+	HTMLURL    string `json:"html_url"`
+	Prerelease bool   `json:"prerelease"`
+	Draft      bool   `json:"draft"`
+
+	Assets []ReleaseAsset `json:"assets"`
+}
+
+type ReleaseAsset struct {
+	Name               string `json:"name"`
+	BrowserDownloadURL string `json:"browser_download_url"`
+	Size               int64  `json:"size"`
+}
+
+func (s *Server) handleReleaseEvent(event GitHubEvent) *Notification {
+	slog.Info("Processing release event", "action", event.Action)
+
+	// Only announce published releases
+	if event.Action != "published" {
+		slog.Info("Ignoring release action", "action", event.Action)
+		return nil
+	}
+
+	// Drafts are never announced
+	if event.Release.Draft {
+		slog.Info("Release is a draft, not sending notification", "tag", event.Release.TagName)
+		return nil
+	}
+
+	// Determine the color based on the release type
+	releaseType := "stable"
+	if event.Release.Prerelease {
+		releaseType = "prerelease"
+	}
+
+	name := event.Release.Name
+	if name == "" {
+		name = event.Release.TagName
+	}
+
+	// Create the notification
+	message := Notification{
+		Title:       fmt.Sprintf("New release %s", event.Release.TagName),
+		Description: truncate(event.Release.Body, maxReleaseBodyLength),
+		Color:       colorFor("release", releaseType),
+		URL:         event.Release.HTMLURL,
+		Fields: []NotificationField{
+			{
+				Name:   "Repository",
+				Value:  fmt.Sprintf("[%s](%s)", event.Repository.FullName, event.Repository.HTMLURL),
+				Inline: true,
+			},
+			{
+				Name:   "Release",
+				Value:  fmt.Sprintf("[%s](%s)", escapeMarkdown(name), event.Release.HTMLURL),
+				Inline: true,
+			},
+		},
+	}
+
+	if event.Release.Prerelease {
+		message.Fields = append(message.Fields, NotificationField{
+			Name:   "Type",
+			Value:  "Prerelease",
+			Inline: true,
+		})
+	}
+
+	// Link the downloadable binaries
+	if assets := event.Release.Assets; len(assets) > 0 {
+		message.Fields = append(message.Fields, NotificationField{
+			Name:  "Assets",
+			Value: formatReleaseAssets(assets),
+		})
+	}
+
+	return &message
+}
+
+// formatReleaseAssets lists assets as download links with their sizes,
+// summarizing any past maxReleaseAssets
+func formatReleaseAssets(assets []ReleaseAsset) string {
+	var lines []string
+	for i, asset := range assets {
+		if i == maxReleaseAssets {
+			lines = append(lines, fmt.Sprintf("+%d more", len(assets)-maxReleaseAssets))
+			break
+		}
+		lines = append(lines, fmt.Sprintf("[%s](%s) (%s)", escapeMarkdown(asset.Name), asset.BrowserDownloadURL, formatSize(asset.Size)))
+	}
+	return strings.Join(lines, "\n")
+}
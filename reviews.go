@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+)
+
+// GitHub pull request review payload structure
+type Review struct {
+	State   string `json:"state"`
+	Body    string `json:"body"`
+	HTMLURL string `json:"html_url"`
+	User    Sender `json:"user"`
+}
+
+func (s *Server) handlePullRequestReviewEvent(event GitHubEvent) *Notification {
+	slog.Info("Processing pull request review event", "action", event.Action, "state", event.Review.State)
+
+	// Only announce submitted reviews
+	if event.Action != "submitted" {
+		slog.Info("Ignoring pull request review action", "action", event.Action)
+		return nil
+	}
+
+	state := strings.ToLower(event.Review.State)
+	verb := "reviewed"
+	switch state {
+	case "approved":
+		verb = "approved"
+	case "changes_requested":
+		verb = "requested changes on"
+	case "commented":
+		verb = "commented on"
+	}
+
+	// Create the notification
+	message := Notification{
+		Title: fmt.Sprintf("%s %s #%d", event.Review.User.Login, verb, event.PullRequest.Number),
+		Description: fmt.Sprintf("**%s** %s [#%d: %s](%s)",
+			event.Review.User.Login,
+			verb,
+			event.PullRequest.Number,
+			escapeMarkdown(event.PullRequest.Title),
+			event.Review.HTMLURL),
+		Color: colorFor("review", state),
+		URL:   event.Review.HTMLURL,
+		Fields: []NotificationField{
+			{
+				Name:   "Repository",
+				Value:  fmt.Sprintf("[%s](%s)", event.Repository.FullName, event.Repository.HTMLURL),
+				Inline: true,
+			},
+		},
+		Username:  s.prIdentity.Username,
+		AvatarURL: s.prIdentity.AvatarURL,
+	}
+
+	// Quote the review summary when the reviewer left one
+	if body := strings.TrimSpace(event.Review.Body); body != "" {
+		message.Description += "\n" + quote(truncate(body, maxCommentLength))
+	}
+
+	return &message
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// Routing configuration mapping GitHub events to Discord webhooks
+type RouteConfig struct {
+	Routes       []Route                      `json:"routes"`
+	RepoWebhooks map[string]string            `json:"repo_webhooks,omitempty"` // Repository full name to webhook, overrides routes
+	LabelRoutes  map[string]string            `json:"label_routes,omitempty"`  // PR label to webhook, overrides routes for labeled PRs
+	Destinations map[string]DestinationConfig `json:"destinations,omitempty"`  // Per-webhook settings keyed by webhook URL
+}
+
+type Route struct {
+	Events       []string `json:"events"`                 // GitHub event types, or "*" for all
+	Repositories []string `json:"repositories,omitempty"` // Repository full names, empty matches any
+	Webhooks     []string `json:"webhooks"`
+}
+
+// Settings for a single webhook
+type DestinationConfig struct {
+	TimeoutSeconds int  `json:"timeout_seconds,omitempty"` // Overrides the default delivery timeout
+	Forum          bool `json:"forum,omitempty"`           // Webhook posts to a Discord forum channel, creating a thread per notification
+
+	// Least severe notifications sent to this webhook, overriding MIN_SEVERITY
+	MinSeverity string `json:"min_severity,omitempty"`
+
+	// Hex colors keyed by outcome ("merged", "failure") or "<event>:<outcome>",
+	// used instead of the global colors for this webhook
+	Colors map[string]string `json:"colors,omitempty"`
+}
+
+// loadRouteConfig reads and validates the routing configuration file at path
+func loadRouteConfig(path string) (*RouteConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var config RouteConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+
+	for i, route := range config.Routes {
+		if len(route.Events) == 0 {
+			return nil, fmt.Errorf("route %d has no events", i)
+		}
+		if len(route.Webhooks) == 0 {
+			return nil, fmt.Errorf("route %d has no webhooks", i)
+		}
+	}
+	for webhook, destination := range config.Destinations {
+		if destination.TimeoutSeconds < 0 {
+			return nil, fmt.Errorf("destination %s has a negative timeout", redactWebhookURL(webhook))
+		}
+		if _, err := parseSeverity(destination.MinSeverity); err != nil {
+			return nil, fmt.Errorf("destination %s: %w", redactWebhookURL(webhook), err)
+		}
+		for key, value := range destination.Colors {
+			if _, err := parseColor(value); err != nil {
+				return nil, fmt.Errorf("destination %s color %s: %w", redactWebhookURL(webhook), key, err)
+			}
+		}
+	}
+
+	return &config, nil
+}
+
+// defaultRouteConfig builds the routes used when no config file is present,
+// mirroring each channel's events to every one of its webhooks. Admin events
+// go to the development channel when no admin channel is set.
+func defaultRouteConfig(developmentWebhooks, testingWebhooks, adminWebhooks []string) *RouteConfig {
+	if len(adminWebhooks) == 0 {
+		adminWebhooks = developmentWebhooks
+	}
+	return &RouteConfig{
+		Routes: []Route{
+			{Events: []string{"pull_request", "pull_request_review", "push", "release", "issues", "issue_comment", "commit_comment", "discussion", "star", "fork", "create", "delete"}, Webhooks: developmentWebhooks},
+			{Events: []string{"workflow_run", "check_run", "check_suite", "deployment_status"}, Webhooks: testingWebhooks},
+			{Events: []string{"membership", "installation", "installation_repositories"}, Webhooks: adminWebhooks},
+		},
+	}
+}
+
+// webhooksFor returns the deduplicated webhooks routed for an event from a repository
+func (rc *RouteConfig) webhooksFor(eventType, repo string) []string {
+	// Repositories with a dedicated webhook bypass the event routes
+	if webhook, ok := rc.RepoWebhooks[repo]; ok {
+		return []string{webhook}
+	}
+
+	var webhooks []string
+	seen := make(map[string]bool)
+	for _, route := range rc.Routes {
+		if !route.matches(eventType, repo) {
+			continue
+		}
+		for _, webhook := range route.Webhooks {
+			if !seen[webhook] {
+				seen[webhook] = true
+				webhooks = append(webhooks, webhook)
+			}
+		}
+	}
+	return webhooks
+}
+
+// labelWebhooksFor returns the deduplicated webhooks routed for a PR's labels
+func (rc *RouteConfig) labelWebhooksFor(labels []Label) []string {
+	var webhooks []string
+	for _, label := range labels {
+		if webhook, ok := rc.LabelRoutes[label.Name]; ok && !contains(webhooks, webhook) {
+			webhooks = append(webhooks, webhook)
+		}
+	}
+	return webhooks
+}
+
+// allWebhooks returns every distinct webhook referenced by the config
+func (rc *RouteConfig) allWebhooks() []string {
+	var webhooks []string
+	seen := make(map[string]bool)
+	add := func(webhook string) {
+		if !seen[webhook] {
+			seen[webhook] = true
+			webhooks = append(webhooks, webhook)
+		}
+	}
+
+	for _, route := range rc.Routes {
+		for _, webhook := range route.Webhooks {
+			add(webhook)
+		}
+	}
+	for _, webhook := range rc.RepoWebhooks {
+		add(webhook)
+	}
+	for _, webhook := range rc.LabelRoutes {
+		add(webhook)
+	}
+	return webhooks
+}
+
+// timeoutFor returns the delivery timeout configured for a webhook, or zero for the default
+func (rc *RouteConfig) timeoutFor(webhook string) time.Duration {
+	return time.Duration(rc.Destinations[webhook].TimeoutSeconds) * time.Second
+}
+
+// colorFor returns the color a webhook overrides for an event outcome,
+// preferring "<event>:<outcome>" over the bare outcome
+func (rc *RouteConfig) colorFor(webhook, eventType, outcome string) (int, bool) {
+	colors := rc.Destinations[webhook].Colors
+	for _, key := range []string{eventType + ":" + outcome, outcome} {
+		if value, ok := colors[key]; ok {
+			color, err := parseColor(value)
+			return color, err == nil
+		}
+	}
+	return 0, false
+}
+
+// minSeverityFor returns the severity threshold for a webhook, falling back to def
+func (rc *RouteConfig) minSeverityFor(webhook string, def severity) severity {
+	if value := rc.Destinations[webhook].MinSeverity; value != "" {
+		level, _ := parseSeverity(value)
+		return level
+	}
+	return def
+}
+
+// isForum reports whether a webhook is configured as a Discord forum channel
+func (rc *RouteConfig) isForum(webhook string) bool {
+	return rc.Destinations[webhook].Forum
+}
+
+func (r Route) matches(eventType, repo string) bool {
+	return contains(r.Events, eventType, "*") && (len(r.Repositories) == 0 || contains(r.Repositories, repo))
+}
+
+// contains reports whether list holds any of the given values
+func contains(list []string, values ...string) bool {
+	for _, item := range list {
+		for _, value := range values {
+			if item == value {
+				return true
+			}
+		}
+	}
+	return false
+}
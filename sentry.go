@@ -0,0 +1,39 @@
+package main
+
+import (
+	"github.com/getsentry/sentry-go"
+)
+
+// Whether delivery failures are reported to Sentry
+var sentryEnabled bool
+
+// reportDeliveryFailure captures a delivery that failed for good, doing
+// nothing when SENTRY_DSN is unset
+func reportDeliveryFailure(job deliveryJob, err error) {
+	if !sentryEnabled {
+		return
+	}
+
+	sentry.WithScope(func(scope *sentry.Scope) {
+		scope.SetTags(map[string]string{
+			"event_type":  job.eventType,
+			"repo":        job.repo,
+			"destination": job.destination,
+		})
+		sentry.CaptureException(err)
+	})
+}
+
+// reportBreakerOpened captures a webhook's circuit breaker opening, once per
+// cooldown rather than for every delivery skipped while it stays open
+func reportBreakerOpened(webhookURL string, err error) {
+	if !sentryEnabled {
+		return
+	}
+
+	sentry.WithScope(func(scope *sentry.Scope) {
+		scope.SetTag("webhook", redactWebhookURL(webhookURL))
+		scope.SetExtra("last_error", err.Error())
+		sentry.CaptureMessage("Circuit breaker opened")
+	})
+}
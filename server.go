@@ -0,0 +1,518 @@
+package main
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Server turns GitHub webhooks into notifications for the configured routes
+type Server struct {
+	// Routes from GitHub events to webhooks, swapped by /reload
+	routesMu sync.RWMutex
+	routes   *RouteConfig
+
+	// Secret used to verify GitHub webhook signatures
+	webhookSecret string
+
+	// Maximum accepted webhook request body size
+	maxBodyBytes int64
+
+	// Recently seen delivery IDs, nil when deduplication is disabled
+	seenDeliveries *deliveryCache
+
+	// Recently sent notification hashes per webhook, nil when disabled
+	seenContent *deliveryCache
+
+	// Repositories whose events are processed, empty allows all. Events with
+	// no repository, such as membership and installation, always pass.
+	repoAllowlist []string
+
+	// Host links in notifications must point at, empty allows any
+	githubHost string
+
+	// Base branch globs a PR must target to be notified, empty allows all
+	prBranchFilter []string
+
+	// Branches and tags that create/delete events notify for, nil allows all
+	refFilter *regexp.Regexp
+
+	// PR label whose addition is announced, empty ignores label changes
+	triggerLabel string
+
+	// Whether to notify when new commits are pushed to an open PR
+	notifyPRSync bool
+
+	// Sender logins whose events are never notified
+	ignoredSenders []string
+
+	// Whether to also notify when workflow runs are requested
+	workflowNotifyStarted bool
+
+	// Workflows whose successful runs are notified, empty notifies all
+	workflowSuccessAllowlist []string
+
+	// Role ID or @here to mention when a workflow fails
+	mentionOnFailure string
+
+	// Role ID or @here to mention when a workflow is cancelled
+	mentionOnCancel string
+
+	// Role ID or @here to mention when a branch is force-pushed
+	mentionOnForcePush string
+
+	// Description templates keyed by event type, replacing the built-in wording
+	templates map[string]*template.Template
+
+	// Endpoint the raw payload is relayed to, empty disables forwarding
+	forwardURL string
+
+	// Endpoint normalized events are posted to, empty disables it
+	analyticsURL string
+
+	// Event types switched on or off by ENABLE_* flags, missing ones are enabled
+	enabledEvents map[string]bool
+
+	// Least severe events notified, per-destination settings take precedence
+	minSeverity severity
+
+	// Commit count above which pushes are summarized per author, zero always lists commits
+	pushSummaryThreshold int
+
+	// Whether titles are prefixed with an emoji for the outcome
+	emojiEnabled bool
+
+	// Whether ?echo=1 returns the parsed event, for debugging struct tags
+	debugEcho bool
+
+	// Per-event bot identity overrides
+	prIdentity botIdentity
+	ciIdentity botIdentity
+
+	// deliver hands a job to the delivery pipeline, replaceable so handlers
+	// can be exercised without sending anything
+	deliver func(job deliveryJob)
+}
+
+// newServer builds a Server from the loaded configuration, delivering through the worker pool
+func newServer(cfg *Config) *Server {
+	s := &Server{
+		routes:                   cfg.Routes,
+		webhookSecret:            cfg.WebhookSecret,
+		maxBodyBytes:             cfg.MaxBodyBytes,
+		repoAllowlist:            cfg.RepoAllowlist,
+		githubHost:               cfg.GitHubHost,
+		prBranchFilter:           cfg.PRBranchFilter,
+		refFilter:                cfg.RefFilter,
+		notifyPRSync:             cfg.NotifyPRSync,
+		triggerLabel:             cfg.TriggerLabel,
+		ignoredSenders:           cfg.IgnoredSenders,
+		workflowNotifyStarted:    cfg.WorkflowNotifyStarted,
+		mentionOnFailure:         cfg.MentionOnFailure,
+		workflowSuccessAllowlist: cfg.WorkflowSuccessAllowlist,
+		mentionOnCancel:          cfg.MentionOnCancel,
+		mentionOnForcePush:       cfg.MentionOnForcePush,
+		templates:                cfg.Templates,
+		forwardURL:               cfg.ForwardURL,
+		analyticsURL:             cfg.AnalyticsURL,
+		debugEcho:                cfg.DebugEcho,
+		emojiEnabled:             cfg.EmojiEnabled,
+		enabledEvents:            cfg.EnabledEvents,
+		pushSummaryThreshold:     cfg.PushSummaryThreshold,
+		minSeverity:              cfg.MinSeverity,
+		prIdentity:               cfg.PRIdentity,
+		ciIdentity:               cfg.CIIdentity,
+		deliver:                  queueDelivery,
+	}
+	if cfg.DedupWindow > 0 {
+		s.seenDeliveries = newDeliveryCache(cfg.DedupWindow)
+	}
+	if cfg.ContentDedupWindow > 0 {
+		s.seenContent = newDeliveryCache(cfg.ContentDedupWindow)
+	}
+	return s
+}
+
+// currentRoutes returns the routing config in effect
+func (s *Server) currentRoutes() *RouteConfig {
+	s.routesMu.RLock()
+	defer s.routesMu.RUnlock()
+	return s.routes
+}
+
+// setRoutes atomically replaces the routing config
+func (s *Server) setRoutes(routes *RouteConfig) {
+	s.routesMu.Lock()
+	defer s.routesMu.Unlock()
+	s.routes = routes
+}
+
+func (s *Server) handleGitHubWebhook(c *gin.Context) {
+	// Get the event type and delivery ID from the headers
+	eventType := c.GetHeader("X-GitHub-Event")
+	deliveryID := c.GetHeader("X-GitHub-Delivery")
+	logger := slog.With("event_type", eventType, "delivery_id", deliveryID)
+	logger.Info("Received GitHub webhook event")
+
+	// GitHub sends either raw JSON or a form with the JSON under "payload"
+	contentType := c.ContentType()
+	if contentType != "application/json" && contentType != "application/x-www-form-urlencoded" {
+		logger.Warn("Unsupported content type", "content_type", contentType)
+		abortWithError(c, 415, errCodeUnsupportedMediaType, "Unsupported Media Type")
+		return
+	}
+
+	// Read the request body, refusing anything larger than the limit
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, s.maxBodyBytes)
+	reader := io.Reader(c.Request.Body)
+
+	// Decompress gzipped deliveries, applying the limit to the decompressed size too
+	gzipped := strings.EqualFold(c.GetHeader("Content-Encoding"), "gzip")
+	if gzipped {
+		gz, err := gzip.NewReader(c.Request.Body)
+		if err != nil {
+			logger.Warn("Invalid gzip request body", "error", err)
+			abortWithError(c, 400, errCodeInvalidGzip, "Invalid gzip body")
+			return
+		}
+		defer gz.Close()
+		reader = io.LimitReader(gz, s.maxBodyBytes+1)
+	}
+
+	body, err := io.ReadAll(reader)
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) || int64(len(body)) > s.maxBodyBytes {
+		logger.Warn("Request body too large", "limit", s.maxBodyBytes)
+		abortWithError(c, 413, errCodePayloadTooLarge, "Request Entity Too Large")
+		return
+	}
+	if err != nil && gzipped {
+		logger.Warn("Invalid gzip request body", "error", err)
+		abortWithError(c, 400, errCodeInvalidGzip, "Invalid gzip body")
+		return
+	}
+	if err != nil {
+		logger.Error("Error reading request body", "error", err)
+		abortWithError(c, 400, errCodeUnreadableBody, "Unable to read request body")
+		return
+	}
+
+	// Verify the payload signature when a secret is configured
+	if s.webhookSecret != "" && !verifyGitHubSignature(body, c.GetHeader("X-Hub-Signature-256"), c.GetHeader("X-Hub-Signature"), s.webhookSecret) {
+		logger.Warn("Invalid or missing webhook signature")
+		abortWithError(c, 401, errCodeInvalidSignature, "Invalid signature")
+		return
+	}
+
+	webhooksReceived.WithLabelValues(eventType).Inc()
+	eventsReceived.Add(1)
+
+	// Relay the untouched delivery without holding up GitHub's response
+	if s.forwardURL != "" {
+		header := c.Request.Header.Clone()
+		go func() {
+			if err := forwardPayload(s.forwardURL, header, body); err != nil {
+				logger.Warn("Error forwarding webhook payload", "error", err)
+			}
+		}()
+	}
+
+	// Extract the JSON payload from form-encoded deliveries
+	payload := body
+	if contentType == "application/x-www-form-urlencoded" {
+		form, err := url.ParseQuery(string(body))
+		if err != nil || form.Get("payload") == "" {
+			logger.Error("Error reading form payload", "error", err)
+			abortWithError(c, 400, errCodeMissingPayload, "Missing payload form value")
+			return
+		}
+		payload = []byte(form.Get("payload"))
+	}
+
+	// Parse the GitHub event
+	var event GitHubEvent
+	if err := json.Unmarshal(payload, &event); err != nil {
+		logger.Error("Error parsing webhook payload", "error", err)
+		abortWithError(c, 400, errCodeInvalidJSON, "Invalid JSON payload")
+		return
+	}
+
+	// Reject payloads missing the fields their handler depends on
+	if field := missingField(eventType, payload); field != "" {
+		logger.Warn("Webhook payload missing required field", "field", field)
+		abortWithError(c, 422, errCodeMissingField, fmt.Sprintf("Missing required field %q", field))
+		return
+	}
+	logger = logger.With("repo", event.Repository.FullName)
+	entry := auditEntry{
+		DeliveryID: deliveryID,
+		EventType:  eventType,
+		Repo:       event.Repository.FullName,
+		Sender:     event.Sender.Login,
+	}
+
+	// Skip deliveries GitHub has already sent us
+	if s.seenDeliveries != nil && deliveryID != "" && s.seenDeliveries.seenRecently(deliveryID, time.Now()) {
+		logger.Info("Ignoring duplicate delivery")
+		audit.record(entry, "duplicate", nil)
+		c.JSON(200, gin.H{"message": "Duplicate delivery ignored"})
+		return
+	}
+
+	// Answer GitHub's setup ping without posting to Discord
+	if eventType == "ping" {
+		logger.Info("Received ping", "zen", event.Zen)
+		audit.record(entry, "ping", nil)
+		c.JSON(200, gin.H{"message": fmt.Sprintf("pong: %s", event.Zen)})
+		return
+	}
+
+	// Drop events from repositories that aren't allowed, without making GitHub
+	// retry. Org and app events carry no repository and aren't filtered.
+	if len(s.repoAllowlist) > 0 && event.Repository.FullName != "" && !contains(s.repoAllowlist, event.Repository.FullName) {
+		logger.Info("Ignoring event from repository not in the allowlist")
+		audit.record(entry, "repo_not_allowed", nil)
+		c.JSON(200, gin.H{"message": "Repository not allowed"})
+		return
+	}
+
+	// Events from ignored senders are acknowledged but never processed
+	if s.isIgnoredSender(event.Sender.Login) {
+		logger.Info("Ignoring event from ignored sender", "sender", event.Sender.Login)
+		audit.record(entry, "ignored_sender", nil)
+		c.JSON(200, gin.H{"message": "Sender ignored"})
+		return
+	}
+
+	// Process different event types
+	message := s.handleEvent(eventType, event, logger)
+	if message == nil {
+		audit.record(entry, "skipped", nil)
+	}
+
+	// Let a custom template reword the description
+	if message != nil {
+		description, ok, err := s.renderTemplate(eventType, event)
+		if err != nil {
+			logger.Warn("Error rendering message template, using built-in description", "error", err)
+		} else if ok {
+			message.Description = description
+		}
+	}
+
+	// Prefix titles with an emoji for scanability
+	if message != nil && s.emojiEnabled {
+		if emoji := emojiFor(eventType, eventOutcome(eventType, event)); emoji != "" {
+			message.Title = emoji + " " + message.Title
+		}
+	}
+
+	// Non-urgent notifications are dropped during quiet hours
+	if message != nil && inQuietHours(time.Now()) && !bypassesQuietHours(eventType, event) {
+		logger.Info("Quiet hours, not sending notification")
+		audit.record(entry, "quiet_hours", nil)
+		message = nil
+	}
+
+	if message != nil {
+		eventsNotified.Add(1)
+		s.dispatch(eventType, event, *message, entry, logger)
+	}
+
+	// Show contributors exactly how the payload was parsed
+	if s.debugEcho && c.Query("echo") == "1" {
+		c.IndentedJSON(200, event)
+		return
+	}
+
+	// Respond to GitHub with a success message
+	c.JSON(200, gin.H{"message": "Webhook received successfully"})
+}
+
+// handleEvent builds the notification for an event, or nil when it shouldn't be notified
+func (s *Server) handleEvent(eventType string, event GitHubEvent, logger *slog.Logger) *Notification {
+	if enabled, ok := s.enabledEvents[eventType]; ok && !enabled {
+		logger.Info("Event type disabled, not sending notification")
+		return nil
+	}
+
+	switch eventType {
+	case "pull_request":
+		return s.handlePullRequestEvent(event)
+	case "pull_request_review":
+		return s.handlePullRequestReviewEvent(event)
+	case "workflow_run":
+		return s.handleWorkflowRunEvent(event)
+	case "push":
+		return s.handlePushEvent(event)
+	case "release":
+		return s.handleReleaseEvent(event)
+	case "issues":
+		return s.handleIssuesEvent(event)
+	case "check_run":
+		return s.handleCheckRunEvent(event)
+	case "check_suite":
+		return s.handleCheckSuiteEvent(event)
+	case "discussion":
+		return s.handleDiscussionEvent(event)
+	case "star":
+		return s.handleStarEvent(event)
+	case "deployment_status":
+		return s.handleDeploymentStatusEvent(event)
+	case "issue_comment":
+		return s.handleIssueCommentEvent(event)
+	case "commit_comment":
+		return s.handleCommitCommentEvent(event)
+	case "create", "delete":
+		return s.handleRefEvent(eventType, event)
+	case "fork":
+		return s.handleForkEvent(event)
+	case "installation":
+		return s.handleInstallationEvent(event)
+	case "installation_repositories":
+		return s.handleInstallationRepositoriesEvent(event)
+	case "membership":
+		return s.handleMembershipEvent(event)
+	}
+	logger.Info("Ignoring unhandled event type")
+	return nil
+}
+
+// dispatch fans a notification out to every webhook routed for the event
+func (s *Server) dispatch(eventType string, event GitHubEvent, message Notification, entry auditEntry, logger *slog.Logger) {
+	// Stamp every notification with the time it was processed
+	if message.Timestamp.IsZero() {
+		message.Timestamp = time.Now().UTC()
+	}
+	message.Footer = notificationFooter
+
+	// Show who triggered the event at the top of the notification
+	if message.Author == nil && event.Sender.Login != "" {
+		message.Author = &NotificationAuthor{
+			Name:    event.Sender.Login,
+			URL:     event.Sender.HTMLURL,
+			IconURL: event.Sender.AvatarURL,
+		}
+	}
+
+	// Keep payloads from injecting links to arbitrary sites
+	if dropped := s.sanitizeLinks(&message); dropped > 0 {
+		logger.Warn("Dropped links outside GITHUB_BASE_URL", "links", dropped)
+	}
+
+	routes := s.currentRoutes()
+	webhooks := routes.webhooksFor(eventType, event.Repository.FullName)
+
+	// Labeled PRs go to their team channels instead
+	if eventType == "pull_request" {
+		if labeled := routes.labelWebhooksFor(event.PullRequest.Labels); len(labeled) > 0 {
+			webhooks = labeled
+		}
+	}
+	if len(webhooks) == 0 {
+		logger.Warn("No routes configured for event")
+	}
+	jobs := make([]deliveryJob, 0, len(webhooks)+1)
+	for _, webhookURL := range webhooks {
+		jobs = append(jobs, deliveryJob{
+			destination: destination,
+			webhookURL:  webhookURL,
+			timeout:     routes.timeoutFor(webhookURL),
+		})
+	}
+
+	// Mirror every notification to Teams when configured
+	if teamsWebhookURL != "" {
+		jobs = append(jobs, deliveryJob{destination: destinationTeams, webhookURL: teamsWebhookURL})
+	}
+	if len(jobs) == 0 {
+		audit.record(entry, "unrouted", nil)
+	}
+
+	// Feed downstream consumers a stable shape instead of GitHub's schema
+	if s.analyticsURL != "" {
+		normalized := normalizeEvent(eventType, event, message)
+		go func() {
+			if err := sendAnalyticsEvent(s.analyticsURL, normalized); err != nil {
+				logger.Warn("Error sending analytics event", "error", err)
+			}
+		}()
+	}
+
+	outcome := eventOutcome(eventType, event)
+	level := severityOf(eventType, event)
+	var hash string
+	if s.seenContent != nil {
+		hash = contentHash(message)
+	}
+
+	for _, job := range jobs {
+		// Only forum channels accept a thread name, and they require one
+		jobMessage := message
+		if job.destination == destinationDiscord && routes.isForum(job.webhookURL) {
+			if jobMessage.ThreadName == "" {
+				jobMessage.ThreadName = jobMessage.Title
+			}
+		} else {
+			jobMessage.ThreadName = ""
+		}
+
+		// Channels may carry their own palette
+		if color, ok := routes.colorFor(job.webhookURL, eventType, outcome); ok {
+			jobMessage.Color = color
+		}
+		job.messages = []Notification{jobMessage}
+		job.eventType = eventType
+		job.repo = event.Repository.FullName
+		job.logger = logger.With("destination", job.destination)
+		entry.Destination = job.destination
+		job.audit = []auditEntry{entry}
+
+		// Quiet channels only get the events important enough for them
+		if level < routes.minSeverityFor(job.webhookURL, s.minSeverity) {
+			job.logger.Info("Event below the minimum severity, skipping")
+			audit.record(entry, "below_severity", nil)
+			continue
+		}
+
+		// Skip a notification identical to one just sent to the same webhook
+		if s.seenContent != nil && s.seenContent.seenRecently(job.webhookURL+"|"+hash, time.Now()) {
+			job.logger.Info("Identical notification sent recently, skipping")
+			audit.record(entry, "duplicate_content", nil)
+			continue
+		}
+
+		// Tracked messages are sent on their own so their ID can be recorded
+		if message.MessageKey == "" {
+			job.batchKey = eventType + "|" + event.Repository.FullName
+		}
+		s.deliver(job)
+	}
+}
+
+// isIgnoredSender reports whether login matches IGNORE_SENDERS, where an
+// entry starting with "*" matches as a suffix (e.g. "*[bot]")
+func (s *Server) isIgnoredSender(login string) bool {
+	for _, ignored := range s.ignoredSenders {
+		if suffix, ok := strings.CutPrefix(ignored, "*"); ok {
+			if strings.HasSuffix(strings.ToLower(login), strings.ToLower(suffix)) {
+				return true
+			}
+		} else if strings.EqualFold(login, ignored) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,254 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Webhooks the test server routes to, never contacted since deliveries are recorded
+const (
+	testDevelopmentWebhook = "https://discord.com/api/webhooks/1/development"
+	testTestingWebhook     = "https://discord.com/api/webhooks/2/testing"
+)
+
+// newTestServer builds a server on the default routes whose deliveries are
+// recorded instead of sent
+func newTestServer(t *testing.T) (*Server, *[]deliveryJob) {
+	t.Helper()
+	s := newServer(&Config{
+		Routes:       defaultRouteConfig([]string{testDevelopmentWebhook}, []string{testTestingWebhook}, nil),
+		MaxBodyBytes: 1 << 20,
+		DedupWindow:  time.Hour,
+	})
+	jobs := &[]deliveryJob{}
+	s.deliver = func(job deliveryJob) {
+		*jobs = append(*jobs, job)
+	}
+	return s, jobs
+}
+
+// newWebhookRequest builds a JSON GitHub delivery of the given event type
+func newWebhookRequest(eventType string, body []byte) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "/webhook/github", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-GitHub-Event", eventType)
+	return req
+}
+
+// serveWebhook runs a request through the webhook handler
+func serveWebhook(s *Server, req *http.Request) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/webhook/github", s.handleGitHubWebhook)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+// decodeResponse unmarshals a JSON response body
+func decodeResponse(t *testing.T, rec *httptest.ResponseRecorder, v any) {
+	t.Helper()
+	if err := json.Unmarshal(rec.Body.Bytes(), v); err != nil {
+		t.Fatalf("decoding response %q: %v", rec.Body.String(), err)
+	}
+}
+
+func TestHandleGitHubWebhookIgnoresDuplicateDelivery(t *testing.T) {
+	s, jobs := newTestServer(t)
+	payload := pullRequestPayload(t, "opened", false)
+
+	var responses []string
+	for i := 0; i < 2; i++ {
+		req := newWebhookRequest("pull_request", payload)
+		req.Header.Set("X-GitHub-Delivery", "72d3162e-cc78-11e3-81ab-4c9367dc0958")
+		rec := serveWebhook(s, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("delivery %d: status = %d, want 200", i+1, rec.Code)
+		}
+		var body struct {
+			Message string `json:"message"`
+		}
+		decodeResponse(t, rec, &body)
+		responses = append(responses, body.Message)
+	}
+
+	if len(*jobs) != 1 {
+		t.Errorf("delivered %d jobs, want 1", len(*jobs))
+	}
+	if responses[1] != "Duplicate delivery ignored" {
+		t.Errorf("second response = %q, want %q", responses[1], "Duplicate delivery ignored")
+	}
+}
+
+func TestHandleGitHubWebhookContentTypes(t *testing.T) {
+	payload := pullRequestPayload(t, "opened", false)
+	tests := []struct {
+		name        string
+		contentType string
+		body        []byte
+		wantStatus  int
+		wantCode    string
+		wantSent    bool
+	}{
+		{
+			name:        "json",
+			contentType: "application/json",
+			body:        payload,
+			wantStatus:  http.StatusOK,
+			wantSent:    true,
+		},
+		{
+			name:        "form payload",
+			contentType: "application/x-www-form-urlencoded",
+			body:        []byte(url.Values{"payload": {string(payload)}}.Encode()),
+			wantStatus:  http.StatusOK,
+			wantSent:    true,
+		},
+		{
+			name:        "form without payload",
+			contentType: "application/x-www-form-urlencoded",
+			body:        []byte(url.Values{"other": {string(payload)}}.Encode()),
+			wantStatus:  http.StatusBadRequest,
+			wantCode:    errCodeMissingPayload,
+		},
+		{
+			name:        "plain text",
+			contentType: "text/plain",
+			body:        payload,
+			wantStatus:  http.StatusUnsupportedMediaType,
+			wantCode:    errCodeUnsupportedMediaType,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, jobs := newTestServer(t)
+			req := newWebhookRequest("pull_request", tt.body)
+			req.Header.Set("Content-Type", tt.contentType)
+			rec := serveWebhook(s, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if tt.wantCode != "" {
+				var body errorResponse
+				decodeResponse(t, rec, &body)
+				if body.Code != tt.wantCode {
+					t.Errorf("code = %q, want %q", body.Code, tt.wantCode)
+				}
+			}
+			if sent := len(*jobs) > 0; sent != tt.wantSent {
+				t.Errorf("sent = %v, want %v", sent, tt.wantSent)
+			}
+		})
+	}
+}
+
+// gzipBytes compresses data as a Content-Encoding gzip body
+func gzipBytes(t *testing.T, data []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if _, err := gz.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestHandleGitHubWebhookGzip(t *testing.T) {
+	payload := pullRequestPayload(t, "opened", false)
+	compressed := gzipBytes(t, payload)
+
+	// Whitespace padding compresses to a few KB but inflates past the 1 MiB limit
+	bomb := gzipBytes(t, append(bytes.Repeat([]byte(" "), 2<<20), payload...))
+
+	tests := []struct {
+		name       string
+		body       []byte
+		wantStatus int
+		wantCode   string
+	}{
+		{name: "gzipped payload", body: compressed, wantStatus: http.StatusOK},
+		{name: "not gzip", body: []byte("definitely not gzip"), wantStatus: http.StatusBadRequest, wantCode: errCodeInvalidGzip},
+		{name: "truncated stream", body: compressed[:len(compressed)/2], wantStatus: http.StatusBadRequest, wantCode: errCodeInvalidGzip},
+		{name: "decompresses past the limit", body: bomb, wantStatus: http.StatusRequestEntityTooLarge, wantCode: errCodePayloadTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, jobs := newTestServer(t)
+			req := newWebhookRequest("pull_request", tt.body)
+			req.Header.Set("Content-Encoding", "gzip")
+			rec := serveWebhook(s, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if tt.wantCode == "" {
+				if len(*jobs) != 1 {
+					t.Errorf("delivered %d jobs, want 1", len(*jobs))
+				}
+				return
+			}
+			var body errorResponse
+			decodeResponse(t, rec, &body)
+			if body.Code != tt.wantCode {
+				t.Errorf("code = %q, want %q", body.Code, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestHandleGitHubWebhookRepoAllowlist(t *testing.T) {
+	tests := []struct {
+		name      string
+		eventType string
+		payload   string
+		wantSent  bool
+	}{
+		{
+			name:      "allowed repository",
+			eventType: "pull_request",
+			payload:   string(pullRequestPayload(t, "opened", false)),
+			wantSent:  true,
+		},
+		{
+			name:      "other repository",
+			eventType: "pull_request",
+			payload:   strings.Replace(string(pullRequestPayload(t, "opened", false)), `"full_name":"octo/repo"`, `"full_name":"octo/other"`, 1),
+		},
+		{
+			name:      "event without a repository",
+			eventType: "membership",
+			payload:   `{"action":"added","scope":"team","member":{"login":"hubot"},"team":{"name":"Core"},"organization":{"login":"octo"},"sender":{"login":"octocat"}}`,
+			wantSent:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, jobs := newTestServer(t)
+			s.repoAllowlist = []string{"octo/repo"}
+			s.enabledEvents = map[string]bool{"membership": true}
+			rec := serveWebhook(s, newWebhookRequest(tt.eventType, []byte(tt.payload)))
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d, want 200: %s", rec.Code, rec.Body.String())
+			}
+			if sent := len(*jobs) > 0; sent != tt.wantSent {
+				t.Errorf("sent = %v, want %v", sent, tt.wantSent)
+			}
+		})
+	}
+}
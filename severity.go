@@ -0,0 +1,63 @@
+package main
+
+import "fmt"
+
+// severity ranks how important a notification is, for MIN_SEVERITY filtering
+type severity int
+
+const (
+	severityInfo severity = iota
+	severityNotice
+	severityAlert
+)
+
+var severityNames = map[string]severity{
+	"info":   severityInfo,
+	"notice": severityNotice,
+	"alert":  severityAlert,
+}
+
+// parseSeverity parses "info", "notice" or "alert", with empty meaning info
+func parseSeverity(value string) (severity, error) {
+	if value == "" {
+		return severityInfo, nil
+	}
+	level, ok := severityNames[value]
+	if !ok {
+		return 0, fmt.Errorf("invalid severity %q, expected info, notice or alert", value)
+	}
+	return level, nil
+}
+
+// severityOf rates an event: failures and force pushes are alerts, merges,
+// releases and other completed milestones are notices, everything else is info
+func severityOf(eventType string, event GitHubEvent) severity {
+	outcome := eventOutcome(eventType, event)
+	switch eventType {
+	case "workflow_run", "check_run", "check_suite":
+		switch outcome {
+		case "failure", "timed_out":
+			return severityAlert
+		case "cancelled", "action_required":
+			return severityNotice
+		}
+	case "deployment_status":
+		switch outcome {
+		case "failure", "error":
+			return severityAlert
+		case "success":
+			return severityNotice
+		}
+	case "push":
+		if event.Forced {
+			return severityAlert
+		}
+	case "pull_request":
+		if outcome == "merged" {
+			return severityNotice
+		}
+	case "release":
+		return severityNotice
+	}
+	return severityInfo
+}
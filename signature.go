@@ -0,0 +1,60 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"net/http"
+	"strings"
+)
+
+// Key signing payloads relayed downstream, empty leaves them unsigned
+var signingKey string
+
+// signBridgePayload sets X-Bridge-Signature to the HMAC of the body, in the
+// same "sha256=<hex digest>" form GitHub signs webhooks with
+func signBridgePayload(header http.Header, body []byte) {
+	if signingKey == "" {
+		return
+	}
+	mac := hmac.New(sha256.New, []byte(signingKey))
+	mac.Write(body)
+	header.Set("X-Bridge-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+}
+
+// verifyGitHubSignature checks the X-Hub-Signature-256 header, falling back to
+// the legacy SHA-1 X-Hub-Signature header sent by older GitHub Enterprise installs
+func verifyGitHubSignature(body []byte, sha256Header, sha1Header, secret string) bool {
+	if sha256Header != "" {
+		return verifySignature(body, sha256Header, secret)
+	}
+	if sha1Header != "" {
+		return verifyHMAC(sha1.New, "sha1=", body, sha1Header, secret)
+	}
+	return false
+}
+
+// verifySignature checks the X-Hub-Signature-256 header against the HMAC of the body
+func verifySignature(body []byte, header string, secret string) bool {
+	return verifyHMAC(sha256.New, "sha256=", body, header, secret)
+}
+
+// verifyHMAC checks a "<prefix><hex digest>" header against the HMAC of the body
+func verifyHMAC(newHash func() hash.Hash, prefix string, body []byte, header, secret string) bool {
+	if !strings.HasPrefix(header, prefix) {
+		return false
+	}
+
+	expected, err := hex.DecodeString(strings.TrimPrefix(header, prefix))
+	if err != nil {
+		return false
+	}
+
+	mac := hmac.New(newHash, []byte(secret))
+	mac.Write(body)
+
+	// Compare in constant time to avoid leaking timing information
+	return hmac.Equal(mac.Sum(nil), expected)
+}
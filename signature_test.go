@@ -0,0 +1,49 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"testing"
+)
+
+// sign returns the "<prefix><hex digest>" signature GitHub sends for body
+func sign(newHash func() hash.Hash, prefix string, body []byte, secret string) string {
+	mac := hmac.New(newHash, []byte(secret))
+	mac.Write(body)
+	return prefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestVerifyGitHubSignature(t *testing.T) {
+	const secret = "It's a Secret to Everybody"
+	body := []byte(`{"zen":"Design for failure."}`)
+	validSHA256 := sign(sha256.New, "sha256=", body, secret)
+	validSHA1 := sign(sha1.New, "sha1=", body, secret)
+	wrongSHA256 := sign(sha256.New, "sha256=", body, "wrong secret")
+	wrongSHA1 := sign(sha1.New, "sha1=", body, "wrong secret")
+
+	tests := []struct {
+		name         string
+		sha256Header string
+		sha1Header   string
+		want         bool
+	}{
+		{name: "sha256 only", sha256Header: validSHA256, want: true},
+		{name: "sha1 only", sha1Header: validSHA1, want: true},
+		{name: "both valid", sha256Header: validSHA256, sha1Header: validSHA1, want: true},
+		{name: "wrong sha256 with valid sha1", sha256Header: wrongSHA256, sha1Header: validSHA1, want: false},
+		{name: "wrong sha1 only", sha1Header: wrongSHA1, want: false},
+		{name: "sha1 digest in sha256 header", sha256Header: validSHA1, want: false},
+		{name: "no signature", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := verifyGitHubSignature(body, tt.sha256Header, tt.sha1Header, secret); got != tt.want {
+				t.Errorf("verifyGitHubSignature = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
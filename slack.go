@@ -0,0 +1,140 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+)
+
+// Slack incoming webhook message structures
+type SlackMessage struct {
+	Text        string            `json:"text,omitempty"`
+	Username    string            `json:"username,omitempty"`
+	IconURL     string            `json:"icon_url,omitempty"`
+	Attachments []SlackAttachment `json:"attachments,omitempty"`
+}
+
+type SlackAttachment struct {
+	Color     string       `json:"color"`
+	Title     string       `json:"title"`
+	TitleLink string       `json:"title_link,omitempty"`
+	Text      string       `json:"text"`
+	Fields    []SlackField `json:"fields,omitempty"`
+	Footer    string       `json:"footer,omitempty"`
+
+	AuthorName string `json:"author_name,omitempty"`
+	AuthorLink string `json:"author_link,omitempty"`
+	AuthorIcon string `json:"author_icon,omitempty"`
+
+	Ts       int64    `json:"ts,omitempty"`
+	MrkdwnIn []string `json:"mrkdwn_in,omitempty"`
+}
+
+type SlackField struct {
+	Title string `json:"title"`
+	Value string `json:"value"`
+	Short bool   `json:"short,omitempty"`
+}
+
+// HTTP client used for all Slack requests, deadlines come from the request context
+var slackClient = &http.Client{}
+
+// SlackNotifier delivers notifications as attachments to a Slack incoming webhook
+type SlackNotifier struct {
+	WebhookURL string
+}
+
+func (s SlackNotifier) Send(ctx context.Context, message Notification) error {
+	return sendSlackMessage(ctx, s.WebhookURL, slackMessageFor(message))
+}
+
+// slackMessageFor translates a notification into a single-attachment Slack message
+func slackMessageFor(n Notification) SlackMessage {
+	attachment := SlackAttachment{
+		Color:     fmt.Sprintf("#%06X", n.Color),
+		Title:     n.Title,
+		TitleLink: n.URL,
+		Text:      slackMarkdown(n.Description),
+		Footer:    n.Footer,
+		MrkdwnIn:  []string{"text", "fields"},
+	}
+	if !n.Timestamp.IsZero() {
+		attachment.Ts = n.Timestamp.Unix()
+	}
+	if n.Author != nil {
+		attachment.AuthorName = n.Author.Name
+		attachment.AuthorLink = n.Author.URL
+		attachment.AuthorIcon = n.Author.IconURL
+	}
+	for _, field := range n.Fields {
+		attachment.Fields = append(attachment.Fields, SlackField{
+			Title: field.Name,
+			Value: slackMarkdown(field.Value),
+			Short: field.Inline,
+		})
+	}
+
+	text := slackMarkdown(n.Content)
+	switch n.Mention {
+	case "":
+	case "@here", "@everyone":
+		text = strings.TrimSpace(fmt.Sprintf("<!%s> %s", strings.TrimPrefix(n.Mention, "@"), text))
+	default:
+		text = strings.TrimSpace(fmt.Sprintf("<!subteam^%s> %s", n.Mention, text))
+	}
+
+	return SlackMessage{
+		Text:        text,
+		Username:    n.Username,
+		IconURL:     n.AvatarURL,
+		Attachments: []SlackAttachment{attachment},
+	}
+}
+
+// slackMarkdown converts the Discord markdown used by the handlers to Slack mrkdwn
+func slackMarkdown(s string) string {
+	s = markdownLink.ReplaceAllString(s, "<$2|$1>")
+	s = markdownBold.ReplaceAllString(s, "*$1*")
+	return unescapeMarkdown(s)
+}
+
+func sendSlackMessage(ctx context.Context, webhookURL string, message SlackMessage) error {
+	// Log the payload instead of sending it in dry-run mode
+	if dryRun {
+		return logDryRun("slack", message)
+	}
+
+	// Convert message to JSON
+	jsonData, err := json.Marshal(message)
+	if err != nil {
+		return fmt.Errorf("marshaling Slack message: %w", err)
+	}
+
+	// Send HTTP POST to Slack webhook
+	reqCtx, cancel := requestContext(ctx)
+	defer cancel()
+	req, err := http.NewRequestWithContext(reqCtx, http.MethodPost, webhookURL, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return fmt.Errorf("creating Slack request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := slackClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("sending Slack message: %w", redactError(err))
+	}
+	defer resp.Body.Close()
+
+	// Check response status
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("Slack API error (status %d): %s", resp.StatusCode, string(bodyBytes))
+	}
+
+	slog.Info("Slack message sent successfully")
+	return nil
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+)
+
+func (s *Server) handleStarEvent(event GitHubEvent) *Notification {
+	slog.Info("Processing star event", "action", event.Action)
+
+	// Only celebrate new stars, not removed ones
+	if event.Action != "created" {
+		slog.Info("Ignoring star action", "action", event.Action)
+		return nil
+	}
+
+	// Create the notification
+	message := Notification{
+		Title: "New star",
+		Description: fmt.Sprintf("⭐ **%s** starred [%s](%s)",
+			event.Sender.Login,
+			event.Repository.FullName,
+			event.Repository.HTMLURL),
+		Color: colorFor("star", event.Action),
+		URL:   event.Repository.HTMLURL,
+	}
+
+	// Show when the star was added rather than when we received it
+	if starredAt, err := time.Parse(time.RFC3339, event.StarredAt); err == nil {
+		message.Timestamp = starredAt
+	}
+
+	// Include the new star count when GitHub sends it
+	if event.Repository.StargazersCount > 0 {
+		message.Fields = append(message.Fields, NotificationField{
+			Name:   "Stars",
+			Value:  strconv.Itoa(event.Repository.StargazersCount),
+			Inline: true,
+		})
+	}
+
+	return &message
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+)
+
+// announceStartup queues a "bridge online" notification for each webhook,
+// confirming a deploy came up. Delivery failures are only logged.
+func announceStartup(webhooks []string) {
+	hostname, _ := os.Hostname()
+	message := Notification{
+		Title:       "Webhook bridge online",
+		Description: fmt.Sprintf("Webhook bridge %s is online", version),
+		Color:       colorFor("startup", "online"),
+		Timestamp:   time.Now().UTC(),
+		Footer:      notificationFooter,
+	}
+	if hostname != "" {
+		message.Fields = []NotificationField{{Name: "Host", Value: hostname, Inline: true}}
+	}
+
+	for _, webhookURL := range webhooks {
+		enqueueDelivery(deliveryJob{
+			destination: destination,
+			webhookURL:  webhookURL,
+			messages:    []Notification{message},
+			eventType:   "startup",
+			logger:      slog.With("event_type", "startup", "destination", destination),
+		})
+	}
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+)
+
+// Microsoft Teams MessageCard structures
+type TeamsMessageCard struct {
+	Type            string               `json:"@type"`
+	Context         string               `json:"@context"`
+	ThemeColor      string               `json:"themeColor"`
+	Summary         string               `json:"summary"`
+	Title           string               `json:"title"`
+	Text            string               `json:"text,omitempty"`
+	Sections        []TeamsSection       `json:"sections,omitempty"`
+	PotentialAction []TeamsOpenURIAction `json:"potentialAction,omitempty"`
+}
+
+type TeamsSection struct {
+	ActivityTitle string `json:"activityTitle,omitempty"`
+	ActivityImage string `json:"activityImage,omitempty"`
+
+	Text     string      `json:"text,omitempty"`
+	Facts    []TeamsFact `json:"facts,omitempty"`
+	Markdown bool        `json:"markdown"`
+}
+
+type TeamsFact struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type TeamsOpenURIAction struct {
+	Type    string           `json:"@type"`
+	Name    string           `json:"name"`
+	Targets []TeamsURITarget `json:"targets"`
+}
+
+type TeamsURITarget struct {
+	OS  string `json:"os"`
+	URI string `json:"uri"`
+}
+
+// HTTP client used for all Teams requests, deadlines come from the request context
+var teamsClient = &http.Client{}
+
+// Teams webhook notified alongside the configured destination, empty when disabled
+var teamsWebhookURL string
+
+// TeamsNotifier delivers notifications as MessageCards to a Teams incoming webhook
+type TeamsNotifier struct {
+	WebhookURL string
+}
+
+func (t TeamsNotifier) Send(ctx context.Context, message Notification) error {
+	return sendTeamsMessage(ctx, t.WebhookURL, teamsMessageFor(message))
+}
+
+// teamsMessageFor translates a notification into a Teams MessageCard
+func teamsMessageFor(n Notification) TeamsMessageCard {
+	section := TeamsSection{
+		Text:     unescapeMarkdown(n.Description),
+		Markdown: true,
+	}
+	if n.Author != nil {
+		section.ActivityTitle = n.Author.Name
+		section.ActivityImage = n.Author.IconURL
+	}
+	for _, field := range n.Fields {
+		section.Facts = append(section.Facts, TeamsFact{Name: field.Name, Value: unescapeMarkdown(field.Value)})
+	}
+
+	card := TeamsMessageCard{
+		Type:       "MessageCard",
+		Context:    "https://schema.org/extensions",
+		ThemeColor: fmt.Sprintf("%06X", n.Color),
+		Summary:    n.Title,
+		Title:      n.Title,
+		Text:       n.Content,
+		Sections:   []TeamsSection{section},
+	}
+	if n.URL != "" {
+		card.PotentialAction = []TeamsOpenURIAction{{
+			Type:    "OpenUri",
+			Name:    "View on GitHub",
+			Targets: []TeamsURITarget{{OS: "default", URI: n.URL}},
+		}}
+	}
+	return card
+}
+
+func sendTeamsMessage(ctx context.Context, webhookURL string, message TeamsMessageCard) error {
+	// Log the payload instead of sending it in dry-run mode
+	if dryRun {
+		return logDryRun("teams", message)
+	}
+
+	// Convert message to JSON
+	jsonData, err := json.Marshal(message)
+	if err != nil {
+		return fmt.Errorf("marshaling Teams message: %w", err)
+	}
+
+	// Send HTTP POST to Teams webhook
+	reqCtx, cancel := requestContext(ctx)
+	defer cancel()
+	req, err := http.NewRequestWithContext(reqCtx, http.MethodPost, webhookURL, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return fmt.Errorf("creating Teams request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := teamsClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("sending Teams message: %w", redactError(err))
+	}
+	defer resp.Body.Close()
+
+	// Check response status
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("Teams API error (status %d): %s", resp.StatusCode, string(bodyBytes))
+	}
+
+	slog.Info("Teams message sent successfully")
+	return nil
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+// Helpers available to message templates
+var templateFuncs = template.FuncMap{
+	"truncate":       truncate,
+	"shortSHA":       shortSHA,
+	"firstLine":      firstLine,
+	"escapeMarkdown": escapeMarkdown,
+}
+
+// loadTemplates parses every <event>.tmpl file in dir, keyed by event type
+func loadTemplates(dir string) (map[string]*template.Template, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	templates := make(map[string]*template.Template)
+	for _, entry := range entries {
+		eventType, ok := strings.CutSuffix(entry.Name(), ".tmpl")
+		if !ok || entry.IsDir() {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		tmpl, err := template.New(entry.Name()).Funcs(templateFuncs).Parse(string(data))
+		if err != nil {
+			return nil, fmt.Errorf("parsing %s: %w", entry.Name(), err)
+		}
+		templates[eventType] = tmpl
+	}
+	return templates, nil
+}
+
+// renderTemplate executes the template for an event type, reporting false
+// when there is none so the built-in description is kept
+func (s *Server) renderTemplate(eventType string, event GitHubEvent) (string, bool, error) {
+	tmpl, ok := s.templates[eventType]
+	if !ok {
+		return "", false, nil
+	}
+
+	var description strings.Builder
+	if err := tmpl.Execute(&description, event); err != nil {
+		return "", false, fmt.Errorf("executing %s: %w", tmpl.Name(), err)
+	}
+	return strings.TrimSpace(description.String()), true, nil
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Markdown patterns used by the handlers, rewritten by destinations that differ
+var (
+	markdownLink = regexp.MustCompile(`\[([^\]]*)\]\(([^)]*)\)`)
+	markdownBold = regexp.MustCompile(`\*\*(.+?)\*\*`)
+)
+
+// formatConclusion turns a CI conclusion like "timed_out" into "Timed out"
+func formatConclusion(conclusion string) string {
+	if conclusion == "" {
+		return conclusion
+	}
+	conclusion = strings.ReplaceAll(conclusion, "_", " ")
+	return strings.ToUpper(conclusion[:1]) + conclusion[1:]
+}
+
+// formatSize renders a byte count in human-readable units, e.g. "4.2 MB"
+func formatSize(bytes int64) string {
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+	div, exp := int64(unit), 0
+	for n := bytes / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(bytes)/float64(div), "KMGTPE"[exp])
+}
+
+// formatDuration renders a duration compactly, e.g. "2m 34s" or "1h 5m"
+func formatDuration(d time.Duration) string {
+	d = d.Round(time.Second)
+	hours, minutes, seconds := int(d.Hours()), int(d.Minutes())%60, int(d.Seconds())%60
+	switch {
+	case hours > 0:
+		return fmt.Sprintf("%dh %dm", hours, minutes)
+	case minutes > 0:
+		return fmt.Sprintf("%dm %ds", minutes, seconds)
+	}
+	return fmt.Sprintf("%ds", seconds)
+}
+
+// truncate cuts s to at most max characters, marking the cut with an ellipsis
+func truncate(s string, max int) string {
+	runes := []rune(s)
+	if len(runes) <= max {
+		return s
+	}
+	return string(runes[:max-1]) + "…"
+}
+
+// Discord markdown control characters escaped in user-supplied text
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	"*", `\*`,
+	"_", `\_`,
+	"~", `\~`,
+	"`", "\\`",
+	"|", `\|`,
+	">", `\>`,
+	"[", `\[`,
+	"]", `\]`,
+)
+
+// escapeMarkdown escapes markdown formatting in user-supplied text
+func escapeMarkdown(s string) string {
+	return markdownEscaper.Replace(s)
+}
+
+// Backslash escape added by escapeMarkdown
+var markdownEscape = regexp.MustCompile(`\\([\\*_~\x60|>\[\]])`)
+
+// unescapeMarkdown reverses escapeMarkdown for destinations whose markdown
+// doesn't understand backslash escapes, such as Slack and Teams
+func unescapeMarkdown(s string) string {
+	return markdownEscape.ReplaceAllString(s, "$1")
+}
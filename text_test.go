@@ -0,0 +1,35 @@
+package main
+
+import "testing"
+
+func TestUnescapeMarkdownReversesEscape(t *testing.T) {
+	for _, s := range []string{"fix_widget", "**bold** and `code`", `a\_b`, "[x] | > ~y~", `trailing\`} {
+		escaped := escapeMarkdown(s)
+		if got := unescapeMarkdown(escaped); got != s {
+			t.Errorf("unescapeMarkdown(%q) = %q, want %q", escaped, got, s)
+		}
+	}
+}
+
+func TestSlackAndTeamsDropDiscordEscapes(t *testing.T) {
+	n := Notification{
+		Description: "**octocat** opened [#7: " + escapeMarkdown("fix_widget") + "](https://github.com/octo/repo/pull/7)",
+		Fields:      []NotificationField{{Name: "Branch", Value: escapeMarkdown("feature_x")}},
+	}
+
+	slack := slackMessageFor(n).Attachments[0]
+	if want := "*octocat* opened <https://github.com/octo/repo/pull/7|#7: fix_widget>"; slack.Text != want {
+		t.Errorf("Slack text = %q, want %q", slack.Text, want)
+	}
+	if slack.Fields[0].Value != "feature_x" {
+		t.Errorf("Slack field = %q, want %q", slack.Fields[0].Value, "feature_x")
+	}
+
+	teams := teamsMessageFor(n).Sections[0]
+	if want := "**octocat** opened [#7: fix_widget](https://github.com/octo/repo/pull/7)"; teams.Text != want {
+		t.Errorf("Teams text = %q, want %q", teams.Text, want)
+	}
+	if teams.Facts[0].Value != "feature_x" {
+		t.Errorf("Teams fact = %q, want %q", teams.Facts[0].Value, "feature_x")
+	}
+}
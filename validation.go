@@ -0,0 +1,52 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// Top-level payload fields each event type can't be handled without, other
+// fields stay optional
+var requiredFields = map[string][]string{
+	"pull_request":        {"action", "pull_request"},
+	"pull_request_review": {"action", "review", "pull_request"},
+	"workflow_run":        {"action", "workflow_run"},
+	"push":                {"ref"},
+	"release":             {"action", "release"},
+	"issues":              {"action", "issue"},
+	"issue_comment":       {"action", "issue", "comment"},
+	"commit_comment":      {"action", "comment"},
+	"check_run":           {"action", "check_run"},
+	"check_suite":         {"action", "check_suite"},
+	"discussion":          {"action", "discussion"},
+	"star":                {"action"},
+	"fork":                {"forkee"},
+	"deployment_status":   {"deployment_status"},
+	"create":              {"ref", "ref_type"},
+	"delete":              {"ref", "ref_type"},
+	"membership":          {"action", "member", "team"},
+	"installation":        {"action", "installation"},
+
+	"installation_repositories": {"action", "installation"},
+}
+
+// missingField returns the first required field absent from the payload, or
+// empty when it has them all. Null and empty string values count as missing.
+func missingField(eventType string, payload []byte) string {
+	fields, ok := requiredFields[eventType]
+	if !ok {
+		return ""
+	}
+
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(payload, &raw); err != nil {
+		return fields[0]
+	}
+	for _, field := range fields {
+		value := bytes.TrimSpace(raw[field])
+		if len(value) == 0 || bytes.Equal(value, []byte("null")) || bytes.Equal(value, []byte(`""`)) {
+			return field
+		}
+	}
+	return ""
+}
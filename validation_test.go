@@ -0,0 +1,55 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestHandleGitHubWebhookRequiresFields(t *testing.T) {
+	tests := []struct {
+		name        string
+		payload     string
+		wantStatus  int
+		wantMessage string
+	}{
+		{
+			name:        "missing action",
+			payload:     `{"pull_request":{"number":7},"repository":{"full_name":"octo/repo"}}`,
+			wantStatus:  http.StatusUnprocessableEntity,
+			wantMessage: `Missing required field "action"`,
+		},
+		{
+			name:        "null pull request",
+			payload:     `{"action":"opened","pull_request":null,"repository":{"full_name":"octo/repo"}}`,
+			wantStatus:  http.StatusUnprocessableEntity,
+			wantMessage: `Missing required field "pull_request"`,
+		},
+		{
+			name:       "well formed",
+			payload:    string(pullRequestPayload(t, "opened", false)),
+			wantStatus: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, _ := newTestServer(t)
+			rec := serveWebhook(s, newWebhookRequest("pull_request", []byte(tt.payload)))
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if tt.wantStatus != http.StatusUnprocessableEntity {
+				return
+			}
+
+			var body errorResponse
+			decodeResponse(t, rec, &body)
+			if body.Code != errCodeMissingField {
+				t.Errorf("code = %q, want %q", body.Code, errCodeMissingField)
+			}
+			if body.Message != tt.wantMessage {
+				t.Errorf("message = %q, want %q", body.Message, tt.wantMessage)
+			}
+		})
+	}
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// GitHub workflow run payload structure
+type WorkflowRun struct {
+	Name       string `json:"name"`
+	Status     string `json:"status"`
+	Conclusion string `json:"conclusion"`
+	HTMLURL    string `json:"html_url"`
+	HeadSHA    string `json:"head_sha"`
+	HeadBranch string `json:"head_branch"`
+
+	// When the current attempt started and when the run last changed
+	RunStartedAt time.Time `json:"run_started_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+
+	// Who the run belongs to, and who triggered this particular attempt
+	Actor           Sender `json:"actor"`
+	TriggeringActor Sender `json:"triggering_actor"`
+}
+
+func (s *Server) handleWorkflowRunEvent(event GitHubEvent) *Notification {
+	slog.Info("Processing workflow run event", "action", event.Action)
+
+	// Optionally announce runs as they start
+	if event.Action == "requested" && s.workflowNotifyStarted {
+		return s.workflowStartedNotification(event)
+	}
+
+	// Only process completed workflow runs
+	if event.Action != "completed" {
+		slog.Info("Ignoring workflow run action", "action", event.Action)
+		return nil
+	}
+
+	// Successes are only worth announcing for the allowlisted workflows
+	if event.WorkflowRun.Conclusion == "success" && len(s.workflowSuccessAllowlist) > 0 && !contains(s.workflowSuccessAllowlist, event.WorkflowRun.Name) {
+		slog.Info("Workflow not in the success allowlist, not sending notification", "workflow", event.WorkflowRun.Name)
+		return nil
+	}
+
+	// Create the notification
+	message := Notification{
+		Title: fmt.Sprintf("Workflow Run %s", event.WorkflowRun.Conclusion),
+		Description: fmt.Sprintf("Workflow **%s** finished: %s",
+			escapeMarkdown(event.WorkflowRun.Name),
+			formatConclusion(event.WorkflowRun.Conclusion)),
+		Color: colorFor("workflow", event.WorkflowRun.Conclusion),
+		URL:   event.WorkflowRun.HTMLURL,
+		Fields: []NotificationField{
+			{
+				Name:   "Repository",
+				Value:  fmt.Sprintf("[%s](%s)", event.Repository.FullName, event.Repository.HTMLURL),
+				Inline: true,
+			},
+			{
+				Name:   "Triggered by",
+				Value:  fmt.Sprintf("[%s](%s)", event.Sender.Login, event.Sender.HTMLURL),
+				Inline: true,
+			},
+		},
+		Username:  s.ciIdentity.Username,
+		AvatarURL: s.ciIdentity.AvatarURL,
+	}
+
+	// Link straight to the commit the run was for
+	if run := event.WorkflowRun; run.HeadSHA != "" {
+		message.Fields = append(message.Fields, NotificationField{
+			Name:   "Commit",
+			Value:  fmt.Sprintf("[`%s`](%s/commit/%s)", shortSHA(run.HeadSHA), event.Repository.HTMLURL, run.HeadSHA),
+			Inline: true,
+		})
+	}
+	if branch := event.WorkflowRun.HeadBranch; branch != "" {
+		message.Fields = append(message.Fields, NotificationField{
+			Name:   "Branch",
+			Value:  fmt.Sprintf("`%s`", branch),
+			Inline: true,
+		})
+	}
+
+	// Show how long the run took when GitHub sent both timestamps
+	if run := event.WorkflowRun; !run.RunStartedAt.IsZero() && run.UpdatedAt.After(run.RunStartedAt) {
+		message.Fields = append(message.Fields, NotificationField{
+			Name:   "Duration",
+			Value:  formatDuration(run.UpdatedAt.Sub(run.RunStartedAt)),
+			Inline: true,
+		})
+	}
+
+	// Ping someone when the run failed
+	if event.WorkflowRun.Conclusion == "failure" {
+		message.Mention = s.mentionOnFailure
+	}
+
+	// Show who started the run apart from who cancelled it
+	if event.WorkflowRun.Conclusion == "cancelled" {
+		message.Fields[1].Name = "Cancelled by"
+		starter := event.WorkflowRun.TriggeringActor
+		if starter.Login == "" {
+			starter = event.WorkflowRun.Actor
+		}
+		if starter.Login != "" {
+			message.Fields = append(message.Fields, NotificationField{
+				Name:   "Started by",
+				Value:  fmt.Sprintf("[%s](%s)", starter.Login, starter.HTMLURL),
+				Inline: true,
+			})
+		}
+		message.Mention = s.mentionOnCancel
+	}
+
+	return &message
+}
+
+// workflowStartedNotification builds the neutral notification for a requested workflow run
+func (s *Server) workflowStartedNotification(event GitHubEvent) *Notification {
+	return &Notification{
+		Title:       fmt.Sprintf("Workflow %s started", event.WorkflowRun.Name),
+		Description: fmt.Sprintf("⏳ Workflow **%s** started", escapeMarkdown(event.WorkflowRun.Name)),
+		Color:       colorFor("workflow", "started"),
+		URL:         event.WorkflowRun.HTMLURL,
+		Fields: []NotificationField{
+			{
+				Name:   "Repository",
+				Value:  fmt.Sprintf("[%s](%s)", event.Repository.FullName, event.Repository.HTMLURL),
+				Inline: true,
+			},
+			{
+				Name:   "Triggered by",
+				Value:  fmt.Sprintf("[%s](%s)", event.Sender.Login, event.Sender.HTMLURL),
+				Inline: true,
+			},
+		},
+		Username:  s.ciIdentity.Username,
+		AvatarURL: s.ciIdentity.AvatarURL,
+	}
+}